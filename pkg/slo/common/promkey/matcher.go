@@ -0,0 +1,263 @@
+package promkey
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MatchType is a PromQL label matcher operator.
+type MatchType string
+
+const (
+	MatchEqual     MatchType = "="
+	MatchNotEqual  MatchType = "!="
+	MatchRegexp    MatchType = "=~"
+	MatchNotRegexp MatchType = "!~"
+)
+
+// Matcher matches one label value. A missing label matches as "" (same as PromQL).
+type Matcher struct {
+	Name  string
+	Type  MatchType
+	Value string
+
+	re *regexp.Regexp
+}
+
+// NewMatcher builds a matcher. Regexps are fully anchored like in PromQL.
+func NewMatcher(t MatchType, name, value string) (Matcher, error) {
+	m := Matcher{Name: name, Type: t, Value: value}
+	switch t {
+	case MatchEqual, MatchNotEqual:
+	case MatchRegexp, MatchNotRegexp:
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return Matcher{}, fmt.Errorf("invalid regexp for %q: %w", name, err)
+		}
+		m.re = re
+	default:
+		return Matcher{}, fmt.Errorf("unknown match type %q", t)
+	}
+	return m, nil
+}
+
+// Matches reports whether the label value v satisfies the matcher.
+func (m Matcher) Matches(v string) bool {
+	switch m.Type {
+	case MatchEqual:
+		return v == m.Value
+	case MatchNotEqual:
+		return v != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(v)
+	case MatchNotRegexp:
+		return !m.re.MatchString(v)
+	default:
+		return false
+	}
+}
+
+func (m Matcher) String() string {
+	return m.Name + string(m.Type) + `"` + EscapeLabelValue(m.Value) + `"`
+}
+
+// Selector is a PromQL-style series selector: metric name + label matchers.
+// Example: rest_client_requests_total{code=~"5..",method!="GET"}
+type Selector struct {
+	Name     string
+	Matchers []Matcher
+}
+
+// ParseSelector parses a selector token. The metric name is required.
+func ParseSelector(token string) (Selector, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return Selector{}, fmt.Errorf("empty selector")
+	}
+	br := strings.IndexByte(token, '{')
+	if br < 0 {
+		return Selector{Name: token}, nil
+	}
+	if !strings.HasSuffix(token, "}") {
+		return Selector{}, fmt.Errorf("invalid selector (missing '}'): %q", token)
+	}
+	name := strings.TrimSpace(token[:br])
+	if name == "" {
+		return Selector{}, fmt.Errorf("invalid selector (missing metric name): %q", token)
+	}
+	ms, err := parseMatchers(token[br+1 : len(token)-1])
+	if err != nil {
+		return Selector{}, err
+	}
+	return Selector{Name: name, Matchers: ms}, nil
+}
+
+// Matches reports whether a series (name + labels) is selected.
+func (s Selector) Matches(name string, labels map[string]string) bool {
+	if name != s.Name {
+		return false
+	}
+	for _, m := range s.Matchers {
+		if !m.Matches(labels[m.Name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the selector canonically (matchers sorted by label name, then operator).
+func (s Selector) String() string {
+	if len(s.Matchers) == 0 {
+		return s.Name
+	}
+	ms := make([]Matcher, len(s.Matchers))
+	copy(ms, s.Matchers)
+	sort.SliceStable(ms, func(i, j int) bool {
+		if ms[i].Name != ms[j].Name {
+			return ms[i].Name < ms[j].Name
+		}
+		return ms[i].Type < ms[j].Type
+	})
+	parts := make([]string, 0, len(ms))
+	for _, m := range ms {
+		parts = append(parts, m.String())
+	}
+	return s.Name + "{" + strings.Join(parts, ",") + "}"
+}
+
+// Select returns the series of values (canonical key -> value) matched by the selector.
+func Select(values map[string]float64, sel Selector) map[string]float64 {
+	out := map[string]float64{}
+	for key, v := range values {
+		if !strings.HasPrefix(key, sel.Name) {
+			continue
+		}
+		name, labels, err := Parse(key)
+		if err != nil || !sel.Matches(name, labels) {
+			continue
+		}
+		out[key] = v
+	}
+	return out
+}
+
+// Aggregation combines the values of several selected series into one.
+type Aggregation string
+
+const (
+	AggSum   Aggregation = "sum"
+	AggMax   Aggregation = "max"
+	AggMin   Aggregation = "min"
+	AggCount Aggregation = "count"
+)
+
+// Known reports whether a is a supported aggregation ("" means sum).
+func (a Aggregation) Known() bool {
+	switch a {
+	case "", AggSum, AggMax, AggMin, AggCount:
+		return true
+	default:
+		return false
+	}
+}
+
+// Apply aggregates values. Empty aggregation is sum. Apply on no values returns 0.
+func (a Aggregation) Apply(values []float64) float64 {
+	if a == AggCount {
+		return float64(len(values))
+	}
+	if len(values) == 0 {
+		return 0
+	}
+	out := values[0]
+	for _, v := range values[1:] {
+		switch a {
+		case AggMax:
+			out = math.Max(out, v)
+		case AggMin:
+			out = math.Min(out, v)
+		default:
+			out += v
+		}
+	}
+	return out
+}
+
+func parseMatchers(s string) ([]Matcher, error) {
+	var out []Matcher
+	i := 0
+	for {
+		for i < len(s) && (s[i] == ' ' || s[i] == ',') {
+			i++
+		}
+		if i >= len(s) {
+			break
+		}
+
+		start := i
+		for i < len(s) && s[i] != '=' && s[i] != '!' {
+			i++
+		}
+		if i >= len(s) {
+			return nil, fmt.Errorf("invalid matchers (missing operator): %q", s)
+		}
+		name := strings.TrimSpace(s[start:i])
+
+		var t MatchType
+		switch {
+		case strings.HasPrefix(s[i:], "=~"):
+			t = MatchRegexp
+		case strings.HasPrefix(s[i:], "!~"):
+			t = MatchNotRegexp
+		case strings.HasPrefix(s[i:], "!="):
+			t = MatchNotEqual
+		case s[i] == '=':
+			t = MatchEqual
+		default:
+			return nil, fmt.Errorf("invalid matchers (bad operator for %q): %q", name, s)
+		}
+		i += len(t)
+
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		if i >= len(s) || s[i] != '"' {
+			return nil, fmt.Errorf("invalid matchers (missing '\"' for %q): %q", name, s)
+		}
+		i++
+
+		var raw bytes.Buffer
+		for {
+			if i >= len(s) {
+				return nil, fmt.Errorf("invalid matchers (unterminated value for %q): %q", name, s)
+			}
+			ch := s[i]
+			if ch == '"' {
+				i++
+				break
+			}
+			if ch == '\\' && i+1 < len(s) {
+				raw.WriteByte('\\')
+				raw.WriteByte(s[i+1])
+				i += 2
+				continue
+			}
+			raw.WriteByte(ch)
+			i++
+		}
+		val, err := UnescapeLabelValue(raw.String())
+		if err != nil {
+			return nil, fmt.Errorf("unescape matcher %q: %w", name, err)
+		}
+		m, err := NewMatcher(t, name, val)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
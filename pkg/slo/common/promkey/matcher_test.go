@@ -0,0 +1,57 @@
+package promkey
+
+import "testing"
+
+func TestParseSelectorMatches(t *testing.T) {
+	sel, err := ParseSelector(`rest_client_requests_total{method!="GET", code=~"5.."}`)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := sel.String(); got != `rest_client_requests_total{code=~"5..",method!="GET"}` {
+		t.Fatalf("unexpected canonical form %q", got)
+	}
+
+	tests := []struct {
+		labels map[string]string
+		want   bool
+	}{
+		{labels: map[string]string{"code": "503", "method": "POST"}, want: true},
+		{labels: map[string]string{"code": "503", "method": "GET"}, want: false},
+		{labels: map[string]string{"code": "5030", "method": "POST"}, want: false}, // anchored
+		{labels: map[string]string{"code": "429", "method": "POST"}, want: false},
+		{labels: map[string]string{"code": "500"}, want: true}, // missing label matches as ""
+	}
+	for _, tt := range tests {
+		if got := sel.Matches("rest_client_requests_total", tt.labels); got != tt.want {
+			t.Fatalf("labels %v: expected %v, got %v", tt.labels, tt.want, got)
+		}
+	}
+}
+
+func TestParseSelectorErrors(t *testing.T) {
+	for _, in := range []string{``, `{code="5"}`, `m{code="5"`, `m{code~"5"}`, `m{code=5}`, `m{code=~"("}`} {
+		if _, err := ParseSelector(in); err == nil {
+			t.Fatalf("expected error for %q", in)
+		}
+	}
+}
+
+func TestSelectAndAggregate(t *testing.T) {
+	values := map[string]float64{
+		`workqueue_depth{name="a"}`:    2,
+		`workqueue_depth{name="b"}`:    5,
+		`workqueue_depth_other{x="1"}`: 100,
+	}
+	sel, _ := ParseSelector(`workqueue_depth{name!=""}`)
+	matched := Select(values, sel)
+	vals := make([]float64, 0, len(matched))
+	for _, v := range matched {
+		vals = append(vals, v)
+	}
+
+	for agg, want := range map[Aggregation]float64{"": 7, AggSum: 7, AggMax: 5, AggMin: 2, AggCount: 2} {
+		if got := agg.Apply(vals); got != want {
+			t.Fatalf("%q: expected %v, got %v", agg, want, got)
+		}
+	}
+}
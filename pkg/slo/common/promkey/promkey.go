@@ -7,6 +7,10 @@ import (
 	"strings"
 )
 
+// InstanceLabel identifies the scraped process of a series when several replicas are scraped
+// (same label name as Prometheus uses for scrape targets).
+const InstanceLabel = "instance"
+
 // Parse parses a Prometheus metric key token into name + labels.
 // token examples:
 //
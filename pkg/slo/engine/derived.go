@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/yeongki/my-operator/pkg/slo/spec"
+	"github.com/yeongki/my-operator/pkg/slo/summary"
+)
+
+// evalDerivedSLI evaluates Compute.Expr over the aliased inputs.
+// Runtime problems are reported without a verdict: missing inputs and division by zero as
+// insufficient data, other evaluation errors and NaN/Inf as invalid data.
+func evalDerivedSLI(s spec.SLISpec, res summary.SLIResult, w window) summary.SLIResult {
+	inputs := map[string]input{}
+	used := make([]string, 0, len(s.Inputs))
+	for _, ref := range s.Inputs {
+		in := newInput(ref)
+		inputs[ref.Alias] = in
+		used = append(used, in.String())
+	}
+	res.InputsUsed = used
+	res.InputsMissing = []string{}
+
+	expr, err := spec.ParseExpr(s.Compute.Expr)
+	if err != nil {
+		// unreachable: Execute skips specs with a broken expression (SLISpec.Validate)
+		return withoutVerdict(res, summary.StatusSkip, summary.ReasonInvalidSpec, err.Error())
+	}
+
+	values := map[spec.ExprRef]float64{}
+	resets := 0
+	for _, ref := range expr.Refs() {
+		in := inputs[ref.Alias]
+		v, r, ok := exprInput(ref.Func, in, w)
+		if !ok {
+			res.InputsMissing = appendUnique(res.InputsMissing, in.String())
+			continue
+		}
+		values[ref] = v
+		resets += r
+	}
+	if len(res.InputsMissing) > 0 {
+		return missingInputs(res)
+	}
+
+	value, err := expr.Eval(values)
+	switch {
+	case errors.Is(err, spec.ErrDivisionByZero):
+		// nothing happened in the window
+		return withoutVerdict(res, summary.StatusInsufficientData, summary.ReasonZeroDenominator,
+			fmt.Sprintf("expression: %v", err))
+	case err != nil:
+		return withoutVerdict(res, summary.StatusDataInvalid, summary.ReasonInvalidValue,
+			fmt.Sprintf("expression: %v", err))
+	case math.IsNaN(value) || math.IsInf(value, 0):
+		return withoutVerdict(res, summary.StatusDataInvalid, summary.ReasonInvalidValue,
+			fmt.Sprintf("expression result is %v", value))
+	}
+	res.Value = &value
+	res.CounterResets = resets
+
+	if s.Judge != nil {
+		res.Status, res.ReasonCode, res.Reason = judge(res, s.Judge.Rules)
+	}
+	noteResets(&res)
+	return res
+}
+
+// exprInput reduces one input over the window as requested by an expression input function.
+func exprInput(fn string, in input, w window) (v float64, resets int, ok bool) {
+	switch fn {
+	case spec.ExprStart:
+		v, ok = in.value(w.start())
+	case spec.ExprEnd:
+		v, ok = in.value(w.end())
+	case spec.ExprDelta:
+		return in.increase(w)
+	default:
+		mode, known := overTimeModes[fn]
+		points := sumPoints(w, []input{in})
+		if !known || len(points) == 0 {
+			return 0, 0, false
+		}
+		v, ok = overWindow(mode, points), true
+	}
+	return v, 0, ok
+}
+
+var overTimeModes = map[string]spec.ComputeMode{
+	spec.ExprMaxOverTime:  spec.ComputeMax,
+	spec.ExprMinOverTime:  spec.ComputeMin,
+	spec.ExprAvgOverTime:  spec.ComputeAvg,
+	spec.ExprLastOverTime: spec.ComputeLast,
+}
+
+func appendUnique(list []string, v string) []string {
+	for _, s := range list {
+		if s == v {
+			return list
+		}
+	}
+	return append(list, v)
+}
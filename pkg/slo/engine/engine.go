@@ -2,7 +2,11 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/yeongki/my-operator/pkg/slo"
@@ -42,19 +46,25 @@ func (e *Engine) Execute(ctx context.Context, req ExecuteRequest) (*summary.Summ
 	start, err := e.fetcher.Fetch(ctx, cfg.StartedAt)
 	if err != nil {
 		// philosophy: "measurement failure is not test failure" → return a Summary with warnings
-		s := e.emptySummary(cfg, []string{fmt.Sprintf("fetch(start) failed: %v", err)})
-		_ = e.writer.Write(req.OutPath, *s)
+		msg := fmt.Sprintf("fetch(start) failed: %v", err)
+		warnings := append(withWarning(req.Warnings, msg), e.fetchWarnings()...)
+		s := e.emptySummary(cfg, warnings, fetchFailedResults(req.Specs, msg))
+		_ = e.write(req.OutPath, s)
 		return s, nil
 	}
 	end, err := e.fetcher.Fetch(ctx, cfg.FinishedAt)
 	if err != nil {
-		s := e.emptySummary(cfg, []string{fmt.Sprintf("fetch(end) failed: %v", err)})
-		_ = e.writer.Write(req.OutPath, *s)
+		msg := fmt.Sprintf("fetch(end) failed: %v", err)
+		warnings := append(withWarning(req.Warnings, msg), e.fetchWarnings()...)
+		s := e.emptySummary(cfg, warnings, fetchFailedResults(req.Specs, msg))
+		_ = e.write(req.OutPath, s)
 		return s, nil
 	}
 
+	w := newWindow(start, end, req.Samples)
+
 	sum := summary.Summary{
-		SchemaVersion: "slo.v3",
+		SchemaVersion: summary.SchemaVersion,
 		GeneratedAt:   time.Now(),
 		Config: summary.RunConfig{
 			RunID:      cfg.RunID,
@@ -68,9 +78,15 @@ func (e *Engine) Execute(ctx context.Context, req ExecuteRequest) (*summary.Summ
 			Format:        cfg.Format,
 			EvidencePaths: cfg.EvidencePaths,
 		},
+		Warnings: append(append([]string(nil), req.Warnings...), e.fetchWarnings()...),
 	}
 
 	for _, s := range req.Specs {
+		if err := s.Validate(); err != nil {
+			// invalid specs are skipped with an explicit reason instead of producing odd values
+			sum.Results = append(sum.Results, invalidResult(s, err))
+			continue
+		}
 		// specItem, ok := e.reg.Get(id)
 		// if !ok {
 		// 	sum.Warnings = append(sum.Warnings, fmt.Sprintf("unknown sli id: %s", id))
@@ -82,19 +98,34 @@ func (e *Engine) Execute(ctx context.Context, req ExecuteRequest) (*summary.Summ
 		// 	continue
 		// }
 		// r := evalSLI(specItem, start.Values, end.Values)
-		r := evalSLI(s, start.Values, end.Values)
+		r := evalSLI(s, w)
+		noteStale(&r, s, w)
+		r.Evidence = evidence(s, w)
 		sum.Results = append(sum.Results, r)
 	}
 
-	if err := e.writer.Write(req.OutPath, sum); err != nil {
+	if err := e.write(req.OutPath, &sum); err != nil {
 		return nil, err
 	}
 	return &sum, nil
 }
 
-func (e *Engine) emptySummary(cfg RunConfig, warnings []string) *summary.Summary {
+// write hands s to the writer and adds the failures a summary.WarningWriter reported
+// instead of returning them (e.g. one broken sink of a summary.MultiWriter).
+func (e *Engine) write(path string, s *summary.Summary) error {
+	if err := e.writer.Write(path, *s); err != nil {
+		return err
+	}
+	if ww, ok := e.writer.(summary.WarningWriter); ok {
+		s.Warnings = append(s.Warnings, ww.TakeWarnings()...)
+	}
+	return nil
+}
+
+// emptySummary is the summary of a run without snapshots: every SLI has insufficient data.
+func (e *Engine) emptySummary(cfg RunConfig, warnings []string, results []summary.SLIResult) *summary.Summary {
 	return &summary.Summary{
-		SchemaVersion: "slo.v3",
+		SchemaVersion: summary.SchemaVersion,
 		GeneratedAt:   time.Now(),
 		Config: summary.RunConfig{
 			RunID:         cfg.RunID,
@@ -105,12 +136,83 @@ func (e *Engine) emptySummary(cfg RunConfig, warnings []string) *summary.Summary
 			Format:        cfg.Format,
 			EvidencePaths: cfg.EvidencePaths,
 		},
-		Results:  []summary.SLIResult{},
+		Results:  results,
 		Warnings: warnings,
 	}
 }
 
-func evalSLI(s spec.SLISpec, start, end map[string]float64) summary.SLIResult {
+// fetchFailedResults reports every spec as lacking data because a snapshot could not be taken.
+func fetchFailedResults(specs []spec.SLISpec, reason string) []summary.SLIResult {
+	out := make([]summary.SLIResult, 0, len(specs))
+	for _, s := range specs {
+		if err := s.Validate(); err != nil {
+			out = append(out, invalidResult(s, err))
+			continue
+		}
+		out = append(out, summary.SLIResult{
+			ID:          s.ID,
+			Title:       s.Title,
+			Unit:        s.Unit,
+			Kind:        s.Kind,
+			Description: s.Description,
+			Status:      summary.StatusInsufficientData,
+			Reason:      reason,
+			ReasonCode:  summary.ReasonFetchFailed,
+		})
+	}
+	return out
+}
+
+func invalidResult(s spec.SLISpec, err error) summary.SLIResult {
+	reason := err.Error()
+	var verr *spec.ValidationError
+	if errors.As(err, &verr) {
+		msgs := make([]string, 0, len(verr.Fields))
+		for _, f := range verr.Fields {
+			msgs = append(msgs, f.Error())
+		}
+		reason = strings.Join(msgs, "; ")
+	}
+	return summary.SLIResult{
+		ID:          s.ID,
+		Title:       s.Title,
+		Unit:        s.Unit,
+		Kind:        s.Kind,
+		Description: s.Description,
+		Status:      summary.StatusSkip,
+		Reason:      "invalid spec: " + reason,
+		ReasonCode:  summary.ReasonInvalidSpec,
+	}
+}
+
+// withoutVerdict records why res has no judge verdict.
+func withoutVerdict(res summary.SLIResult, status summary.Status, code summary.ReasonCode,
+	reason string) summary.SLIResult {
+	res.Status, res.ReasonCode, res.Reason = status, code, reason
+	return res
+}
+
+func missingInputs(res summary.SLIResult) summary.SLIResult {
+	return withoutVerdict(res, summary.StatusInsufficientData, summary.ReasonMissingInput, "missing input metrics")
+}
+
+// fetchWarnings drains the non-fatal problems collected by the fetcher (see fetch.WarningReporter),
+// e.g. snapshots that only succeeded after a retry.
+func (e *Engine) fetchWarnings() []string {
+	if r, ok := e.fetcher.(fetch.WarningReporter); ok {
+		return r.TakeWarnings()
+	}
+	return nil
+}
+
+// withWarning appends msg without touching the caller's backing array.
+func withWarning(warnings []string, msg string) []string {
+	out := make([]string, 0, len(warnings)+1)
+	out = append(out, warnings...)
+	return append(out, msg)
+}
+
+func evalSLI(s spec.SLISpec, w window) summary.SLIResult {
 	res := summary.SLIResult{
 		ID:          s.ID,
 		Title:       s.Title,
@@ -120,80 +222,347 @@ func evalSLI(s spec.SLISpec, start, end map[string]float64) summary.SLIResult {
 		Status:      summary.StatusPass,
 	}
 
+	if s.Compute.Mode == spec.ComputeHistogramQuantile {
+		return evalHistogramSLI(s, res, w)
+	}
+	if isWindowMode(s.Compute.Mode) {
+		return evalWindowSLI(s, res, w)
+	}
+	if s.Compute.Mode == spec.ComputeRatio {
+		return evalRatioSLI(s, res, w)
+	}
+	if s.Compute.Mode == spec.ComputeDerived {
+		return evalDerivedSLI(s, res, w)
+	}
+
+	needStart, needEnd, ok := snapshotsFor(s.Compute.Mode)
+	if !ok {
+		return withoutVerdict(res, summary.StatusSkip, summary.ReasonUnknownMode, "unknown compute mode")
+	}
+
+	start, end := w.start(), w.end()
 	used := make([]string, 0, len(s.Inputs))
 	missing := make([]string, 0)
 
 	// v3: one-input SLI recommended. If multiple inputs exist, we sum them.
-	var valStart, valEnd float64
-	for _, in := range s.Inputs {
-		used = append(used, in.Key)
-		a, okA := start[in.Key]
-		b, okB := end[in.Key]
-		if !okA || !okB {
-			missing = append(missing, in.Key)
+	var valStart, valEnd, valDelta float64
+	resets := 0
+	for _, ref := range s.Inputs {
+		in := newInput(ref)
+		used = append(used, in.String())
+		a, okA := in.value(start)
+		b, okB := in.value(end)
+		if (needStart && !okA) || (needEnd && !okB) {
+			missing = append(missing, in.String())
 			continue
 		}
 		valStart += a
 		valEnd += b
+		if s.Compute.Mode == spec.ComputeDelta {
+			// reset correction is per series, before aggregating
+			inc, r, _ := in.increase(w)
+			valDelta += inc
+			resets += r
+		}
 	}
 	res.InputsUsed = used
 	res.InputsMissing = missing
 
 	if len(missing) > 0 {
-		res.Status = summary.StatusSkip
-		res.Reason = "missing input metrics"
-		return res
+		return missingInputs(res)
 	}
 
 	var value float64
 	switch s.Compute.Mode {
-	case spec.ComputeSingle:
+	case spec.ComputeSingle, spec.ComputeStart:
 		value = valStart
+	case spec.ComputeEnd:
+		value = valEnd
 	case spec.ComputeDelta:
-		value = valEnd - valStart
-		if value < 0 {
-			// v3: counter reset suspected (process restart)
-			res.Value = &value
-			res.Status = summary.StatusWarn
-			res.Reason = "delta < 0 (counter reset suspected)"
-			// judge가 있으면 judge 결과로 덮어써버리니까,
-			// 이 경우 judge를 건너뛰는 정책을 택할지 결정해야 함.
-			return res // judge skip
-		}
+		// counter resets (process restart) are corrected like PromQL increase()
+		value = valDelta
+		res.CounterResets = resets
 	default:
-		res.Status = summary.StatusSkip
-		res.Reason = "unknown compute mode"
-		return res
+		return withoutVerdict(res, summary.StatusSkip, summary.ReasonUnknownMode, "unknown compute mode")
 	}
 	res.Value = &value
 
 	if s.Judge != nil {
-		res.Status, res.Reason = judge(value, s.Judge.Rules)
+		res.Status, res.ReasonCode, res.Reason = judge(res, s.Judge.Rules)
 	}
+	noteResets(&res)
 
 	return res
 }
 
-func judge(v float64, rules []spec.Rule) (status summary.Status, reason string) {
+// evalWindowSLI reduces the per-sample sum of inputs over the whole window (max/min/avg/last).
+// Selector inputs are aggregated within each sample first. Samples where any input is absent are ignored.
+func evalWindowSLI(s spec.SLISpec, res summary.SLIResult, w window) summary.SLIResult {
+	used := make([]string, 0, len(s.Inputs))
+	missing := make([]string, 0)
+	inputs := make([]input, 0, len(s.Inputs))
+	for _, ref := range s.Inputs {
+		in := newInput(ref)
+		inputs = append(inputs, in)
+		used = append(used, in.String())
+		if !in.seen(w) {
+			missing = append(missing, in.String())
+		}
+	}
+	res.InputsUsed = used
+	res.InputsMissing = missing
+
+	points := sumPoints(w, inputs)
+	if len(missing) > 0 || len(points) == 0 {
+		return missingInputs(res)
+	}
+
+	value := overWindow(s.Compute.Mode, points)
+	res.Value = &value
+
+	if s.Judge != nil {
+		res.Status, res.ReasonCode, res.Reason = judge(res, s.Judge.Rules)
+	}
+	return res
+}
+
+// evalRatioSLI divides the increase of the numerator input by the increase of the denominator input.
+// A zero denominator (nothing happened in the window) is a skip, not a 0% or 100% ratio.
+func evalRatioSLI(s spec.SLISpec, res summary.SLIResult, w window) summary.SLIResult {
+	used := make([]string, 0, len(s.Inputs))
+	missing := make([]string, 0)
+	byAlias := map[string]float64{}
+	resets := 0
+	for _, ref := range s.Inputs {
+		in := newInput(ref)
+		used = append(used, in.String())
+		inc, r, ok := in.increase(w)
+		if !ok {
+			missing = append(missing, in.String())
+			continue
+		}
+		byAlias[ref.Alias] = inc
+		resets += r
+	}
+	res.InputsUsed = used
+	res.InputsMissing = missing
+
+	if len(missing) > 0 {
+		return missingInputs(res)
+	}
+
+	num, den := byAlias[s.Compute.Numerator], byAlias[s.Compute.Denominator]
+	res.Fields = map[string]float64{spec.FieldNumerator: num, spec.FieldDenominator: den}
+	res.CounterResets = resets
+	if den == 0 {
+		return withoutVerdict(res, summary.StatusInsufficientData, summary.ReasonZeroDenominator,
+			fmt.Sprintf("denominator %q is zero in window", s.Compute.Denominator))
+	}
+
+	value := num / den
+	res.Value = &value
+
+	if s.Judge != nil {
+		res.Status, res.ReasonCode, res.Reason = judge(res, s.Judge.Rules)
+	}
+	noteResets(&res)
+	return res
+}
+
+// noteResets explains a pass that relied on counter reset correction.
+func noteResets(res *summary.SLIResult) {
+	if res.CounterResets > 0 && res.Reason == "" {
+		res.ReasonCode = summary.ReasonCounterReset
+		res.Reason = fmt.Sprintf("counter reset corrected (%d)", res.CounterResets)
+	}
+}
+
+// noteStale lists input series whose exposed timestamp did not advance between the start and
+// end snapshots (the collector did not update them during the window) and explains a result
+// that relied on them. Series without exposed timestamps are never reported.
+func noteStale(res *summary.SLIResult, s spec.SLISpec, w window) {
+	start, end := w.samples[0], w.samples[len(w.samples)-1]
+	if len(start.Timestamps) == 0 || len(end.Timestamps) == 0 {
+		return
+	}
+	var stale []string
+	for _, ref := range s.Inputs {
+		for _, k := range inputSeries(s.Compute.Mode, newInput(ref), end.Values) {
+			a, okA := start.Timestamps[k]
+			b, okB := end.Timestamps[k]
+			if okA && okB && !b.After(a) {
+				stale = append(stale, k)
+			}
+		}
+	}
+	res.StaleSeries = stale
+	if len(stale) > 0 && res.Reason == "" {
+		res.ReasonCode = summary.ReasonStaleSeries
+		res.Reason = fmt.Sprintf("%d input series not updated during window", len(stale))
+	}
+}
+
+// inputSeries returns the series keys in values an input reads, sorted.
+// Histogram inputs read their _bucket series.
+func inputSeries(mode spec.ComputeMode, in input, values map[string]float64) []string {
+	if mode != spec.ComputeHistogramQuantile {
+		return in.series(values)
+	}
+	keys := make([]string, 0)
+	for k := range bucketSeries(values, in) {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// evidence records every input series at the start and end of the window.
+// A series present in only one snapshot has the other value unset.
+func evidence(s spec.SLISpec, w window) *summary.Evidence {
+	start, end := w.start(), w.end()
+	ev := &summary.Evidence{Samples: len(w.samples)}
+	for _, ref := range s.Inputs {
+		in := newInput(ref)
+		keys := inputSeries(s.Compute.Mode, in, start)
+		for _, k := range inputSeries(s.Compute.Mode, in, end) {
+			if _, ok := start[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			se := summary.SeriesEvidence{Input: in.String(), Series: k}
+			if v, ok := start[k]; ok {
+				se.Start = &v
+			}
+			if v, ok := end[k]; ok {
+				se.End = &v
+			}
+			ev.Series = append(ev.Series, se)
+		}
+	}
+	return ev
+}
+
+// snapshotsFor reports which snapshots a scalar compute mode reads.
+// v3 single keeps its original contract: inputs must exist in both snapshots.
+func snapshotsFor(mode spec.ComputeMode) (needStart, needEnd, ok bool) {
+	switch mode {
+	case spec.ComputeSingle, spec.ComputeDelta:
+		return true, true, true
+	case spec.ComputeStart:
+		return true, false, true
+	case spec.ComputeEnd:
+		return false, true, true
+	default:
+		return false, false, false
+	}
+}
+
+// evalHistogramSLI computes quantiles from the reset-corrected bucket increase over the window.
+// Inputs are summed by le, so several inputs behave like one histogram.
+func evalHistogramSLI(s spec.SLISpec, res summary.SLIResult, w window) summary.SLIResult {
+	used := make([]string, 0, len(s.Inputs))
+	missing := make([]string, 0)
+
+	byLE := map[float64]float64{}
+	resets := 0
+	inputs := make([]input, 0, len(s.Inputs))
+	for _, ref := range s.Inputs {
+		in := newInput(ref)
+		inputs = append(inputs, in)
+		used = append(used, in.String())
+		inc, r, ok := histogramIncrease(w, in)
+		if !ok {
+			missing = append(missing, in.String())
+			continue
+		}
+		for ub, v := range inc {
+			byLE[ub] += v
+		}
+		resets += r
+	}
+	res.InputsUsed = used
+	res.InputsMissing = missing
+
+	if len(missing) > 0 {
+		return missingInputs(res)
+	}
+
+	buckets := sortedBuckets(byLE)
+	res.CounterResets = resets
+
+	count := buckets[len(buckets)-1].Count
+	res.Fields = map[string]float64{"count": count}
+	if count == 0 {
+		return withoutVerdict(res, summary.StatusInsufficientData, summary.ReasonNoObservations,
+			"no observations in window")
+	}
+
+	quantiles := s.Compute.Quantiles
+	if len(quantiles) == 0 {
+		quantiles = spec.DefaultQuantiles
+	}
+	for _, q := range quantiles {
+		v := bucketQuantile(q, buckets)
+		if math.IsNaN(v) {
+			return withoutVerdict(res, summary.StatusDataInvalid, summary.ReasonNoInfBucket,
+				"histogram has no +Inf bucket")
+		}
+		res.Fields[spec.QuantileField(q)] = v
+		if ex, ok := bucketExemplar(w, inputs, v); ok {
+			ex.Field = spec.QuantileField(q)
+			res.Exemplars = append(res.Exemplars, ex)
+		}
+	}
+
+	if s.Judge != nil {
+		res.Status, res.ReasonCode, res.Reason = judge(res, s.Judge.Rules)
+	}
+	noteResets(&res)
+	return res
+}
+
+// ruleValue resolves the number a rule targets: Value for "value", otherwise a Fields entry.
+func ruleValue(res summary.SLIResult, metric string) (float64, bool) {
+	if metric == "" || metric == spec.MetricValue {
+		if res.Value == nil {
+			return 0, false
+		}
+		return *res.Value, true
+	}
+	v, ok := res.Fields[metric]
+	return v, ok
+}
+
+func judge(res summary.SLIResult, rules []spec.Rule) (summary.Status, summary.ReasonCode, string) {
 	// v3: fail dominates warn
 	var warn string
 	for _, r := range rules {
+		metric := r.Metric
+		if metric == "" {
+			metric = spec.MetricValue
+		}
+		v, ok := ruleValue(res, metric)
+		if !ok {
+			return summary.StatusSkip, summary.ReasonRuleMetric, fmt.Sprintf("rule metric %q not available", metric)
+		}
 		if !compare(v, r.Op, r.Target) {
 			continue
 		}
 		switch r.Level {
 		case spec.LevelFail:
-			return summary.StatusFail, fmt.Sprintf("rule fail: value %s %v", r.Op, r.Target)
+			return summary.StatusFail, summary.ReasonRuleFail, fmt.Sprintf("rule fail: %s %s %v", metric, r.Op, r.Target)
 		case spec.LevelWarn:
-			warn = fmt.Sprintf("rule warn: value %s %v", r.Op, r.Target)
+			warn = fmt.Sprintf("rule warn: %s %s %v", metric, r.Op, r.Target)
 		default:
-			// TODO(v4): unknown level -> warn/skip?
+			// unreachable: Execute skips specs with an unknown level (SLISpec.Validate)
 		}
 	}
 	if warn != "" {
-		return summary.StatusWarn, warn
+		return summary.StatusWarn, summary.ReasonRuleWarn, warn
 	}
-	return summary.StatusPass, ""
+	return summary.StatusPass, "", ""
 }
 
 func compare(v float64, op spec.Op, target float64) bool {
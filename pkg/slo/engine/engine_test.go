@@ -0,0 +1,623 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yeongki/my-operator/pkg/slo/common/promkey"
+	"github.com/yeongki/my-operator/pkg/slo/fetch"
+	"github.com/yeongki/my-operator/pkg/slo/spec"
+	"github.com/yeongki/my-operator/pkg/slo/summary"
+)
+
+// fakeFetcher serves samples by call order (start, end).
+type fakeFetcher struct {
+	samples []fetch.Sample
+	calls   []time.Time
+}
+
+func (f *fakeFetcher) Fetch(_ context.Context, at time.Time) (fetch.Sample, error) {
+	f.calls = append(f.calls, at)
+	sample := f.samples[0]
+	f.samples = f.samples[1:]
+	sample.At = at
+	return sample, nil
+}
+
+type nopWriter struct{}
+
+func (nopWriter) Write(string, summary.Summary) error { return nil }
+
+func TestExecuteComputeModes(t *testing.T) {
+	const key = `workqueue_depth{name="joboperator"}`
+	start := map[string]float64{key: 2, "only_start": 7}
+	end := map[string]float64{key: 5, "only_end": 9}
+
+	tests := []struct {
+		name  string
+		mode  spec.ComputeMode
+		key   string
+		want  summary.Status
+		value float64
+	}{
+		{name: "v3 single uses start", mode: spec.ComputeSingle, key: key, want: summary.StatusPass, value: 2},
+		{name: "v3 delta", mode: spec.ComputeDelta, key: key, want: summary.StatusPass, value: 3},
+		{name: "v3 single needs both", mode: spec.ComputeSingle, key: "only_start", want: summary.StatusInsufficientData},
+		{name: "v4 start", mode: spec.V4ComputeStart, key: key, want: summary.StatusPass, value: 2},
+		{name: "v4 end", mode: spec.V4ComputeEnd, key: key, want: summary.StatusPass, value: 5},
+		{name: "v4 delta", mode: spec.V4ComputeDelta, key: key, want: summary.StatusPass, value: 3},
+		{name: "v4 start ignores end", mode: spec.V4ComputeStart, key: "only_start", want: summary.StatusPass, value: 7},
+		{name: "v4 end ignores start", mode: spec.V4ComputeEnd, key: "only_end", want: summary.StatusPass, value: 9},
+		{name: "v4 end missing at end", mode: spec.V4ComputeEnd, key: "only_start",
+			want: summary.StatusInsufficientData},
+		{name: "unknown mode", mode: "p42", key: key, want: summary.StatusSkip},
+		{name: "non-canonical key", mode: spec.ComputeDelta, key: `a{z="1", b="2"}`, want: summary.StatusSkip},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := &fakeFetcher{samples: []fetch.Sample{{Values: start}, {Values: end}}}
+			eng := New(fetcher, nopWriter{}, nil)
+
+			startedAt := time.Now().Add(-time.Minute)
+			finishedAt := time.Now()
+			sum, err := ExecuteV4(context.Background(), eng, ExecuteRequestV4{
+				Method: InsideSnapshot,
+				Config: RunConfig{StartedAt: startedAt, FinishedAt: finishedAt},
+				Specs: []spec.SLISpec{{
+					ID:      "sli",
+					Inputs:  []spec.MetricRef{spec.UnsafePromKey(tt.key)},
+					Compute: spec.ComputeSpec{Mode: tt.mode},
+				}},
+			})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if len(fetcher.calls) != 2 || !fetcher.calls[0].Equal(startedAt) || !fetcher.calls[1].Equal(finishedAt) {
+				t.Fatalf("expected fetch at start then end, got %v", fetcher.calls)
+			}
+			if len(sum.Results) != 1 {
+				t.Fatalf("expected 1 result, got %d", len(sum.Results))
+			}
+			got := sum.Results[0]
+			if got.Status != tt.want {
+				t.Fatalf("expected status %s, got %s (%s)", tt.want, got.Status, got.Reason)
+			}
+			if tt.want != summary.StatusPass {
+				return
+			}
+			if got.Value == nil || *got.Value != tt.value {
+				t.Fatalf("expected value %v, got %v", tt.value, got.Value)
+			}
+		})
+	}
+}
+
+func TestDeltaCorrectsCounterResets(t *testing.T) {
+	const key = `controller_runtime_reconcile_total{controller="joboperator",result="success"}`
+	s := spec.SLISpec{
+		ID:      "reconcile_success_delta",
+		Inputs:  []spec.MetricRef{spec.UnsafePromKey(key)},
+		Compute: spec.ComputeSpec{Mode: spec.ComputeDelta},
+		Judge: &spec.JudgeSpec{Rules: []spec.Rule{
+			{Metric: spec.MetricValue, Op: spec.OpGT, Target: 100, Level: spec.LevelFail},
+		}},
+	}
+
+	tests := []struct {
+		name   string
+		points []float64
+		value  float64
+		resets int
+		want   summary.Status
+	}{
+		{name: "no reset", points: []float64{10, 15, 20}, value: 10, want: summary.StatusPass},
+		{name: "reset between samples", points: []float64{10, 30, 5, 8}, value: 28, resets: 1, want: summary.StatusPass},
+		{name: "reset without samples", points: []float64{50, 7}, value: 7, resets: 1, want: summary.StatusPass},
+		{name: "judge still runs", points: []float64{90, 150, 2, 60}, value: 120, resets: 1, want: summary.StatusFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := make([]map[string]float64, 0, len(tt.points))
+			for _, p := range tt.points {
+				values = append(values, map[string]float64{key: p})
+			}
+			res := evalSLI(s, testWindow(values...))
+			if res.Status != tt.want {
+				t.Fatalf("expected status %s, got %s (%s)", tt.want, res.Status, res.Reason)
+			}
+			if res.Value == nil || *res.Value != tt.value {
+				t.Fatalf("expected value %v, got %v", tt.value, res.Value)
+			}
+			if res.CounterResets != tt.resets {
+				t.Fatalf("expected %d resets, got %d", tt.resets, res.CounterResets)
+			}
+		})
+	}
+}
+
+func TestExecuteUsesRequestSamples(t *testing.T) {
+	const key = `controller_runtime_reconcile_total{controller="joboperator",result="success"}`
+	startedAt := time.Now().Add(-time.Minute)
+	finishedAt := time.Now()
+	fetcher := &fakeFetcher{samples: []fetch.Sample{
+		{Values: map[string]float64{key: 10}},
+		{Values: map[string]float64{key: 8}},
+	}}
+	eng := New(fetcher, nopWriter{}, nil)
+
+	sum, err := ExecuteV4(context.Background(), eng, ExecuteRequestV4{
+		Method: InsideSnapshot,
+		Config: RunConfig{StartedAt: startedAt, FinishedAt: finishedAt},
+		Specs: []spec.SLISpec{{
+			ID:      "reconcile_success_delta",
+			Inputs:  []spec.MetricRef{spec.UnsafePromKey(key)},
+			Compute: spec.ComputeSpec{Mode: spec.ComputeDelta},
+		}},
+		Samples: []fetch.Sample{
+			{At: startedAt.Add(20 * time.Second), Values: map[string]float64{key: 30}},
+			{At: startedAt.Add(40 * time.Second), Values: map[string]float64{key: 5}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	// 10 -> 30 -> reset -> 5 -> 8: 20 + 5 + 3
+	res := sum.Results[0]
+	if res.Value == nil || *res.Value != 28 || res.CounterResets != 1 {
+		t.Fatalf("expected delta 28 with 1 reset, got %v (%d resets)", res.Value, res.CounterResets)
+	}
+}
+
+func TestWindowModesUseSamples(t *testing.T) {
+	const key = `workqueue_depth{name="joboperator"}`
+	w := testWindow(
+		map[string]float64{key: 0},
+		map[string]float64{key: 7},
+		map[string]float64{},
+		map[string]float64{key: 3},
+		map[string]float64{key: 2},
+	)
+
+	tests := []struct {
+		mode  spec.ComputeMode
+		value float64
+	}{
+		{mode: spec.ComputeMax, value: 7},
+		{mode: spec.ComputeMin, value: 0},
+		{mode: spec.ComputeAvg, value: 3},
+		{mode: spec.ComputeLast, value: 2},
+		{mode: spec.ComputeEnd, value: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			res := evalSLI(spec.SLISpec{
+				ID:      "depth",
+				Inputs:  []spec.MetricRef{spec.UnsafePromKey(key)},
+				Compute: spec.ComputeSpec{Mode: tt.mode},
+			}, w)
+			if res.Status != summary.StatusPass {
+				t.Fatalf("expected pass, got %s (%s)", res.Status, res.Reason)
+			}
+			if res.Value == nil || *res.Value != tt.value {
+				t.Fatalf("expected value %v, got %v", tt.value, res.Value)
+			}
+		})
+	}
+}
+
+func TestSamplerCollectsBetweenStartAndStop(t *testing.T) {
+	fetcher := &countingFetcher{}
+	sampler := NewSampler(fetcher, 5*time.Millisecond, nil)
+	sampler.Start(context.Background())
+	time.Sleep(40 * time.Millisecond)
+	samples := sampler.Stop()
+
+	if len(samples) == 0 {
+		t.Fatalf("expected samples, got none")
+	}
+	for i := 1; i < len(samples); i++ {
+		if samples[i].At.Before(samples[i-1].At) {
+			t.Fatalf("expected samples ordered by time")
+		}
+	}
+	n := len(samples)
+	time.Sleep(20 * time.Millisecond)
+	if len(sampler.Stop()) != n {
+		t.Fatalf("expected no samples after Stop")
+	}
+}
+
+type countingFetcher struct{ n float64 }
+
+func (f *countingFetcher) Fetch(_ context.Context, at time.Time) (fetch.Sample, error) {
+	f.n++
+	return fetch.Sample{At: at, Values: map[string]float64{"workqueue_depth": f.n}}, nil
+}
+
+func TestExecuteSkipsInvalidSpecs(t *testing.T) {
+	fetcher := &fakeFetcher{samples: []fetch.Sample{
+		{Values: map[string]float64{"m": 1}},
+		{Values: map[string]float64{"m": 2}},
+	}}
+	eng := New(fetcher, nopWriter{}, nil)
+
+	sum, err := eng.Execute(context.Background(), ExecuteRequest{
+		Config: RunConfig{StartedAt: time.Now().Add(-time.Minute), FinishedAt: time.Now()},
+		Specs: []spec.SLISpec{
+			{
+				ID:      "bad_level",
+				Inputs:  []spec.MetricRef{spec.UnsafePromKey("m")},
+				Compute: spec.ComputeSpec{Mode: spec.ComputeDelta},
+				Judge:   &spec.JudgeSpec{Rules: []spec.Rule{{Op: spec.OpGT, Target: 0, Level: "critical"}}},
+			},
+			{
+				ID:      "good",
+				Inputs:  []spec.MetricRef{spec.UnsafePromKey("m")},
+				Compute: spec.ComputeSpec{Mode: spec.ComputeDelta},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	bad, good := sum.Results[0], sum.Results[1]
+	const wantReason = `invalid spec: judge.rules[0].level: unknown level "critical" (want warn|fail)`
+	if bad.Status != summary.StatusSkip || bad.Reason != wantReason {
+		t.Fatalf("unexpected result for invalid spec: %s (%s)", bad.Status, bad.Reason)
+	}
+	if good.Status != summary.StatusPass {
+		t.Fatalf("expected valid spec to pass, got %s (%s)", good.Status, good.Reason)
+	}
+}
+
+// flakyFetcher fails the first call, then serves values.
+type flakyFetcher struct{ calls int }
+
+func (f *flakyFetcher) Fetch(_ context.Context, at time.Time) (fetch.Sample, error) {
+	f.calls++
+	if f.calls == 1 {
+		return fetch.Sample{}, errors.New("curl: (7) connection refused")
+	}
+	return fetch.Sample{At: at, Values: map[string]float64{"m": float64(f.calls)}}, nil
+}
+
+func TestExecuteReportsRetriedFetches(t *testing.T) {
+	retry := fetch.NewRetryFetcher(&flakyFetcher{}, fetch.RetryPolicy{InitialBackoff: time.Millisecond})
+	eng := New(retry, nopWriter{}, nil)
+
+	sum, err := eng.Execute(context.Background(), ExecuteRequest{
+		Config: RunConfig{StartedAt: time.Now().Add(-time.Minute), FinishedAt: time.Now()},
+		Specs: []spec.SLISpec{
+			{ID: "m", Inputs: []spec.MetricRef{spec.UnsafePromKey("m")}, Compute: spec.ComputeSpec{Mode: spec.ComputeDelta}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if sum.Results[0].Status != summary.StatusPass {
+		t.Fatalf("expected pass after retry, got %s (%s)", sum.Results[0].Status, sum.Results[0].Reason)
+	}
+	if len(sum.Warnings) != 1 || !strings.Contains(sum.Warnings[0], "succeeded after 2 attempts") ||
+		!strings.Contains(sum.Warnings[0], "connection refused") {
+		t.Fatalf("unexpected warnings %v", sum.Warnings)
+	}
+}
+
+type failingFetcher struct{}
+
+func (failingFetcher) Fetch(context.Context, time.Time) (fetch.Sample, error) {
+	return fetch.Sample{}, errors.New("connection refused")
+}
+
+func TestExecuteReportsFetchFailurePerSLI(t *testing.T) {
+	eng := New(failingFetcher{}, nopWriter{}, nil)
+	sum, err := eng.Execute(context.Background(), ExecuteRequest{
+		Config: RunConfig{StartedAt: time.Now().Add(-time.Minute), FinishedAt: time.Now()},
+		Specs: []spec.SLISpec{
+			{ID: "m", Inputs: []spec.MetricRef{spec.UnsafePromKey("m")}, Compute: spec.ComputeSpec{Mode: spec.ComputeDelta}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if sum.SchemaVersion != summary.SchemaV4 || len(sum.Results) != 1 || len(sum.Warnings) != 1 {
+		t.Fatalf("unexpected summary %+v", sum)
+	}
+	res := sum.Results[0]
+	if res.Status != summary.StatusInsufficientData || res.ReasonCode != summary.ReasonFetchFailed ||
+		!strings.Contains(res.Reason, "connection refused") {
+		t.Fatalf("expected fetch_failed result, got %s/%s (%s)", res.Status, res.ReasonCode, res.Reason)
+	}
+}
+
+func TestExecuteRecordsEvidence(t *testing.T) {
+	fetcher := &fakeFetcher{samples: []fetch.Sample{
+		{Values: map[string]float64{`reconcile_total{result="ok"}`: 1, `reconcile_total{result="error"}`: 2}},
+		{Values: map[string]float64{`reconcile_total{result="ok"}`: 4, `reconcile_total{result="retry"}`: 1}},
+	}}
+	eng := New(fetcher, nopWriter{}, nil)
+	sum, err := eng.Execute(context.Background(), ExecuteRequest{
+		Config: RunConfig{StartedAt: time.Now().Add(-time.Minute), FinishedAt: time.Now()},
+		Specs: []spec.SLISpec{{
+			ID:      "reconciles",
+			Inputs:  []spec.MetricRef{spec.PromSelector("reconcile_total", "")},
+			Compute: spec.ComputeSpec{Mode: spec.ComputeEnd},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	ev := sum.Results[0].Evidence
+	if ev == nil || ev.Samples != 2 || len(ev.Series) != 3 {
+		t.Fatalf("expected evidence for 3 series in 2 samples, got %+v", ev)
+	}
+	byKey := map[string]summary.SeriesEvidence{}
+	for _, se := range ev.Series {
+		byKey[se.Series] = se
+	}
+	errs, retry := byKey[`reconcile_total{result="error"}`], byKey[`reconcile_total{result="retry"}`]
+	if errs.Start == nil || *errs.Start != 2 || errs.End != nil || retry.Start != nil || retry.End == nil {
+		t.Fatalf("expected series present in one snapshot only, got %+v / %+v", errs, retry)
+	}
+}
+
+func TestExecuteReportsStaleSeries(t *testing.T) {
+	at := time.Now()
+	fetcher := &fakeFetcher{samples: []fetch.Sample{
+		{Values: map[string]float64{"queue_depth": 3, "reconcile_total": 1},
+			Timestamps: map[string]time.Time{"queue_depth": at.Add(-time.Hour), "reconcile_total": at}},
+		{Values: map[string]float64{"queue_depth": 3, "reconcile_total": 4},
+			Timestamps: map[string]time.Time{"queue_depth": at.Add(-time.Hour), "reconcile_total": at.Add(time.Minute)}},
+	}}
+	eng := New(fetcher, nopWriter{}, nil)
+
+	sum, err := eng.Execute(context.Background(), ExecuteRequest{
+		Config: RunConfig{StartedAt: at, FinishedAt: at.Add(time.Minute)},
+		Specs: []spec.SLISpec{
+			{ID: "depth", Inputs: []spec.MetricRef{spec.UnsafePromKey("queue_depth")},
+				Compute: spec.ComputeSpec{Mode: spec.ComputeDelta}},
+			{ID: "reconciles", Inputs: []spec.MetricRef{spec.UnsafePromKey("reconcile_total")},
+				Compute: spec.ComputeSpec{Mode: spec.ComputeDelta}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	depth, reconciles := sum.Results[0], sum.Results[1]
+	if len(depth.StaleSeries) != 1 || depth.StaleSeries[0] != "queue_depth" ||
+		depth.Reason != "1 input series not updated during window" {
+		t.Fatalf("expected stale queue_depth, got %v (%s)", depth.StaleSeries, depth.Reason)
+	}
+	if len(reconciles.StaleSeries) != 0 || reconciles.Reason != "" {
+		t.Fatalf("expected fresh reconcile_total, got %v (%s)", reconciles.StaleSeries, reconciles.Reason)
+	}
+}
+
+func TestSelectorInputs(t *testing.T) {
+	start := map[string]float64{
+		`rest_client_requests_total{code="200",method="GET"}`: 100,
+		`rest_client_requests_total{code="500",method="GET"}`: 3,
+		`workqueue_depth{name="a"}`:                           1,
+		`workqueue_depth{name="b"}`:                           4,
+	}
+	end := map[string]float64{
+		`rest_client_requests_total{code="200",method="GET"}`: 180,
+		`rest_client_requests_total{code="500",method="GET"}`: 5,
+		// created during the window: counts from 0
+		`rest_client_requests_total{code="503",method="PUT"}`: 2,
+		`workqueue_depth{name="a"}`:                           6,
+		`workqueue_depth{name="b"}`:                           2,
+	}
+
+	tests := []struct {
+		name  string
+		ref   spec.MetricRef
+		mode  spec.ComputeMode
+		want  summary.Status
+		value float64
+	}{
+		{name: "regexp delta", ref: spec.PromSelector(`rest_client_requests_total{code=~"5.."}`, ""),
+			mode: spec.ComputeDelta, want: summary.StatusPass, value: 4},
+		{name: "name only delta", ref: spec.PromSelector("rest_client_requests_total", promkey.AggSum),
+			mode: spec.ComputeDelta, want: summary.StatusPass, value: 84},
+		{name: "count at end", ref: spec.PromSelector(`rest_client_requests_total{code!="200"}`, promkey.AggCount),
+			mode: spec.ComputeEnd, want: summary.StatusPass, value: 2},
+		{name: "max per sample", ref: spec.PromSelector("workqueue_depth", promkey.AggMax),
+			mode: spec.ComputeMax, want: summary.StatusPass, value: 6},
+		{name: "sum per sample", ref: spec.PromSelector("workqueue_depth", ""),
+			mode: spec.ComputeMin, want: summary.StatusPass, value: 5},
+		{name: "no match", ref: spec.PromSelector(`workqueue_depth{name="zzz"}`, ""),
+			mode: spec.ComputeEnd, want: summary.StatusInsufficientData},
+		// v3 flat-map convenience: a key without labels sums every series of the name
+		{name: "bare key delta", ref: spec.PromMetric("rest_client_requests_total", nil),
+			mode: spec.ComputeDelta, want: summary.StatusPass, value: 84},
+		{name: "bare key at end", ref: spec.PromMetric("workqueue_depth", nil),
+			mode: spec.ComputeEnd, want: summary.StatusPass, value: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := spec.SLISpec{ID: "sli", Inputs: []spec.MetricRef{tt.ref}, Compute: spec.ComputeSpec{Mode: tt.mode}}
+			if err := s.Validate(); err != nil {
+				t.Fatalf("expected valid spec, got %v", err)
+			}
+			res := evalSLI(s, testWindow(start, end))
+			if res.Status != tt.want {
+				t.Fatalf("expected status %s, got %s (%s)", tt.want, res.Status, res.Reason)
+			}
+			if tt.want == summary.StatusPass && (res.Value == nil || *res.Value != tt.value) {
+				t.Fatalf("expected value %v, got %v", tt.value, res.Value)
+			}
+		})
+	}
+}
+
+func TestLeaderPinnedInputs(t *testing.T) {
+	start := map[string]float64{
+		`leader_election_master_status{instance="pod-a",name="my-operator"}`:  0,
+		`leader_election_master_status{instance="pod-b",name="my-operator"}`:  1,
+		`controller_runtime_reconcile_total{instance="pod-a",result="error"}`: 2,
+		`controller_runtime_reconcile_total{instance="pod-b",result="error"}`: 10,
+	}
+	end := map[string]float64{
+		`leader_election_master_status{instance="pod-a",name="my-operator"}`:  0,
+		`leader_election_master_status{instance="pod-b",name="my-operator"}`:  1,
+		`controller_runtime_reconcile_total{instance="pod-a",result="error"}`: 3,
+		`controller_runtime_reconcile_total{instance="pod-b",result="error"}`: 15,
+	}
+	const sel = `controller_runtime_reconcile_total{result="error"}`
+
+	tests := []struct {
+		name  string
+		ref   spec.MetricRef
+		end   map[string]float64
+		want  summary.Status
+		value float64
+	}{
+		{name: "all instances", ref: spec.MetricRef{Selector: sel}, end: end, want: summary.StatusPass, value: 6},
+		{name: "leader only", ref: spec.MetricRef{Selector: sel, Leader: true}, end: end,
+			want: summary.StatusPass, value: 5},
+		{name: "no leader at end", ref: spec.MetricRef{Selector: sel, Leader: true},
+			end: map[string]float64{
+				`controller_runtime_reconcile_total{instance="pod-a",result="error"}`: 3,
+				`controller_runtime_reconcile_total{instance="pod-b",result="error"}`: 15,
+			}, want: summary.StatusInsufficientData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := spec.SLISpec{ID: "sli", Inputs: []spec.MetricRef{tt.ref}, Compute: spec.ComputeSpec{Mode: spec.ComputeDelta}}
+			if err := s.Validate(); err != nil {
+				t.Fatalf("expected valid spec, got %v", err)
+			}
+			res := evalSLI(s, testWindow(start, tt.end))
+			if res.Status != tt.want {
+				t.Fatalf("expected status %s, got %s (%s)", tt.want, res.Status, res.Reason)
+			}
+			if tt.want == summary.StatusPass && (res.Value == nil || *res.Value != tt.value) {
+				t.Fatalf("expected value %v, got %v", tt.value, res.Value)
+			}
+		})
+	}
+
+	bad := spec.SLISpec{ID: "sli", Inputs: []spec.MetricRef{{Key: sel, Leader: true}},
+		Compute: spec.ComputeSpec{Mode: spec.ComputeDelta}}
+	if err := bad.Validate(); err == nil || !strings.Contains(err.Error(), "leader requires a selector") {
+		t.Fatalf("expected leader validation error, got %v", err)
+	}
+}
+
+func TestRatioSLI(t *testing.T) {
+	const errKey = `controller_runtime_reconcile_total{result="error"}`
+	const okKey = `controller_runtime_reconcile_total{result="success"}`
+	s := spec.SLISpec{
+		ID: "reconcile_error_ratio",
+		Inputs: []spec.MetricRef{
+			{Selector: errKey, Alias: "errors"},
+			{Selector: "controller_runtime_reconcile_total", Alias: "total"},
+		},
+		Compute: spec.ComputeSpec{Mode: spec.ComputeRatio, Numerator: "errors", Denominator: "total"},
+		Judge: &spec.JudgeSpec{Rules: []spec.Rule{
+			{Metric: spec.MetricValue, Op: spec.OpGT, Target: 0.2, Level: spec.LevelFail},
+		}},
+	}
+	if err := s.Validate(); err != nil {
+		t.Fatalf("expected valid spec, got %v", err)
+	}
+
+	res := evalSLI(s, testWindow(
+		map[string]float64{errKey: 1, okKey: 10},
+		map[string]float64{errKey: 3, okKey: 28},
+	))
+	// 2 errors / 20 reconciles
+	if res.Status != summary.StatusPass || res.Value == nil || *res.Value != 0.1 {
+		t.Fatalf("expected pass with 0.1, got %s %v (%s)", res.Status, res.Value, res.Reason)
+	}
+	if res.Fields[spec.FieldNumerator] != 2 || res.Fields[spec.FieldDenominator] != 20 {
+		t.Fatalf("unexpected fields %v", res.Fields)
+	}
+
+	idle := map[string]float64{errKey: 3, okKey: 28}
+	res = evalSLI(s, testWindow(idle, idle))
+	if res.Status != summary.StatusInsufficientData || res.ReasonCode != summary.ReasonZeroDenominator ||
+		res.Value != nil {
+		t.Fatalf("expected insufficient data on zero denominator, got %s %v", res.Status, res.Value)
+	}
+}
+
+func TestRatioRequiresAliases(t *testing.T) {
+	s := spec.SLISpec{
+		ID:      "ratio",
+		Inputs:  []spec.MetricRef{{Key: "a", Alias: "a"}, {Key: "b", Alias: "a"}},
+		Compute: spec.ComputeSpec{Mode: spec.ComputeRatio, Numerator: "a", Denominator: "b"},
+	}
+	var verr *spec.ValidationError
+	if !errors.As(s.Validate(), &verr) || len(verr.Fields) != 2 {
+		t.Fatalf("expected duplicate alias and unknown denominator, got %v", s.Validate())
+	}
+
+	s = spec.SLISpec{
+		ID:      "ratio",
+		Inputs:  []spec.MetricRef{{Key: "a", Alias: "a"}, {Key: "b", Alias: "b"}, {Key: "c"}, {Key: "d", Alias: "d"}},
+		Compute: spec.ComputeSpec{Mode: spec.ComputeRatio, Numerator: "a", Denominator: "b"},
+	}
+	if !errors.As(s.Validate(), &verr) || len(verr.Fields) != 2 ||
+		verr.Fields[0].Path != "inputs[2]" || verr.Fields[1].Path != "inputs[3]" {
+		t.Fatalf("expected the unused inputs to be rejected, got %v", s.Validate())
+	}
+}
+
+func TestDerivedSLI(t *testing.T) {
+	const errKey = `controller_runtime_reconcile_total{result="error"}`
+	const depthKey = `workqueue_depth{name="joboperator"}`
+	s := spec.SLISpec{
+		ID: "derived",
+		Inputs: []spec.MetricRef{
+			{Key: errKey, Alias: "errors"},
+			{Selector: "controller_runtime_reconcile_total", Alias: "total"},
+			{Key: depthKey, Alias: "depth"},
+		},
+		Compute: spec.ComputeSpec{Mode: spec.ComputeDerived},
+	}
+
+	w := testWindow(
+		map[string]float64{errKey: 1, depthKey: 3},
+		map[string]float64{errKey: 2, depthKey: 9},
+		map[string]float64{errKey: 4, depthKey: 1},
+	)
+	tests := []struct {
+		expr   string
+		want   summary.Status
+		value  float64
+		reason string
+	}{
+		{expr: "delta(errors) / max(delta(total), 1) * 100", want: summary.StatusPass, value: 100},
+		{expr: "max_over_time(depth) - end(depth)", want: summary.StatusPass, value: 8},
+		{expr: "delta(errors) / (end(depth) - 1)", want: summary.StatusInsufficientData,
+			reason: "expression: division by zero"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s := s
+			s.Compute.Expr = tt.expr
+			if err := s.Validate(); err != nil {
+				t.Fatalf("expected valid spec, got %v", err)
+			}
+			res := evalSLI(s, w)
+			if res.Status != tt.want {
+				t.Fatalf("expected status %s, got %s (%s)", tt.want, res.Status, res.Reason)
+			}
+			if tt.want == summary.StatusPass && (res.Value == nil || *res.Value != tt.value) {
+				t.Fatalf("expected value %v, got %v", tt.value, res.Value)
+			}
+			if !strings.HasPrefix(res.Reason, tt.reason) {
+				t.Fatalf("expected reason %q, got %q", tt.reason, res.Reason)
+			}
+		})
+	}
+}
@@ -3,16 +3,19 @@ package engine
 import (
 	"context"
 
+	"github.com/yeongki/my-operator/pkg/slo/fetch"
 	"github.com/yeongki/my-operator/pkg/slo/spec"
 	"github.com/yeongki/my-operator/pkg/slo/summary"
 )
 
 // ExecuteRequestV4 is the v4 request shape.
 type ExecuteRequestV4 struct {
-	Method  MeasurementMethod
-	Config  RunConfig
-	Specs   []spec.SLISpec
-	OutPath string
+	Method   MeasurementMethod
+	Config   RunConfig
+	Specs    []spec.SLISpec
+	OutPath  string
+	Samples  []fetch.Sample
+	Warnings []string
 }
 
 // ExecuteV4 applies v4 defaults and delegates to the v3 engine.
@@ -26,8 +29,10 @@ func ExecuteV4(ctx context.Context, eng *Engine, req ExecuteRequestV4) (*summary
 		Trigger:  string(mode.Trigger),
 	}
 	return eng.Execute(ctx, ExecuteRequest{
-		Config:  req.Config,
-		Specs:   req.Specs,
-		OutPath: req.OutPath,
+		Config:   req.Config,
+		Specs:    req.Specs,
+		OutPath:  req.OutPath,
+		Samples:  req.Samples,
+		Warnings: req.Warnings,
 	})
 }
@@ -0,0 +1,227 @@
+package engine
+
+import (
+	"maps"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yeongki/my-operator/pkg/slo/common/promkey"
+	"github.com/yeongki/my-operator/pkg/slo/fetch/promtext"
+	"github.com/yeongki/my-operator/pkg/slo/summary"
+)
+
+// histogramIncrease computes the per-le increase of "<name>_bucket" series whose labels
+// contain all labels of the input key, or match its selector
+// (PromQL: sum by (le) (increase(name_bucket{...}[window]))).
+// Each bucket series is reset-corrected on its own before summing; a series missing at
+// start was created during the window and counts from 0.
+// It reports false when no bucket series matched at the end of the window.
+func histogramIncrease(w window, in input) (byLE map[float64]float64, resets int, ok bool) {
+	series := bucketSeries(w.end(), in)
+	if len(series) == 0 {
+		return nil, 0, false
+	}
+
+	start := w.start()
+	byLE = map[float64]float64{}
+	for k, ub := range series {
+		pts := w.points(k)
+		if _, ok := start[k]; !ok {
+			pts = append([]float64{0}, pts...)
+		}
+		inc, r := increase(pts)
+		byLE[ub] += inc
+		resets += r
+	}
+	return byLE, resets, true
+}
+
+// bucketSeries returns "<name>_bucket" series keys matching the input, with their parsed le.
+func bucketSeries(values map[string]float64, in input) map[string]float64 {
+	var (
+		name  string
+		match func(labels map[string]string) bool
+	)
+	if in.sel != nil {
+		name = in.sel.Name
+		keep := in.instanceFilter(values)
+		match = func(labels map[string]string) bool {
+			return in.sel.Matches(in.sel.Name, labels) && (keep == nil || keep(labels))
+		}
+	} else {
+		n, want, err := promkey.Parse(in.ref.Key)
+		if err != nil {
+			return nil
+		}
+		name = n
+		match = func(labels map[string]string) bool { return containsLabels(labels, want) }
+	}
+	prefix := name + "_bucket{"
+
+	out := map[string]float64{}
+	for k := range values {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		_, labels, err := promkey.Parse(k)
+		if err != nil {
+			continue
+		}
+		le, ok := labels["le"]
+		if !ok || !match(labels) {
+			continue
+		}
+		ub, err := strconv.ParseFloat(le, 64)
+		if err != nil {
+			continue
+		}
+		out[k] = ub
+	}
+	return out
+}
+
+// bucketExemplar returns the exemplar of the bucket a quantile value v falls in (the matching
+// bucket series with the smallest le >= v), e.g. the trace of a reconcile behind p99.
+// Only exemplars new in the end snapshot count: one already exposed unchanged at start, or
+// timestamped before the window, belongs to an earlier observation. The slowest one wins.
+func bucketExemplar(w window, inputs []input, v float64) (summary.Exemplar, bool) {
+	start, end := w.samples[0], w.samples[len(w.samples)-1]
+	if len(end.Exemplars) == 0 {
+		return summary.Exemplar{}, false
+	}
+
+	series := map[string]float64{}
+	target := math.Inf(1)
+	for _, in := range inputs {
+		for k, ub := range bucketSeries(end.Values, in) {
+			series[k] = ub
+			if ub >= v && ub < target {
+				target = ub
+			}
+		}
+	}
+	keys := make([]string, 0, len(series))
+	for k, ub := range series {
+		if ub == target {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var (
+		best  summary.Exemplar
+		found bool
+	)
+	for _, k := range keys {
+		ex, ok := end.Exemplars[k]
+		if !ok {
+			continue
+		}
+		if prev, ok := start.Exemplars[k]; ok && sameExemplar(prev, ex) {
+			continue
+		}
+		if !ex.Timestamp.IsZero() && ex.Timestamp.Before(start.At) {
+			continue
+		}
+		if found && ex.Value <= best.Value {
+			continue
+		}
+		best = summary.Exemplar{Series: k, Labels: ex.Labels, Value: ex.Value}
+		if !ex.Timestamp.IsZero() {
+			ts := ex.Timestamp
+			best.Timestamp = &ts
+		}
+		found = true
+	}
+	return best, found
+}
+
+func sameExemplar(a, b promtext.Exemplar) bool {
+	return a.Value == b.Value && a.Timestamp.Equal(b.Timestamp) && maps.Equal(a.Labels, b.Labels)
+}
+
+func containsLabels(labels, want map[string]string) bool {
+	for k, v := range want {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedBuckets(byLE map[float64]float64) []promtext.Bucket {
+	out := make([]promtext.Bucket, 0, len(byLE))
+	for ub, c := range byLE {
+		out = append(out, promtext.Bucket{UpperBound: ub, Count: c})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].UpperBound < out[j].UpperBound })
+	return out
+}
+
+// bucketQuantile calculates quantile q from cumulative buckets sorted by UpperBound.
+// It mirrors PromQL histogram_quantile:
+//   - the highest bucket must be +Inf, otherwise NaN is returned
+//   - if q falls into the +Inf bucket, the upper bound of the second highest bucket is returned
+//   - within a bucket, the value is linearly interpolated (lower bound 0 for the first bucket)
+func bucketQuantile(q float64, buckets []promtext.Bucket) float64 {
+	if math.IsNaN(q) {
+		return math.NaN()
+	}
+	if q < 0 {
+		return math.Inf(-1)
+	}
+	if q > 1 {
+		return math.Inf(+1)
+	}
+	if len(buckets) < 2 || !math.IsInf(buckets[len(buckets)-1].UpperBound, +1) {
+		return math.NaN()
+	}
+	buckets = ensureMonotonic(buckets)
+
+	observations := buckets[len(buckets)-1].Count
+	if observations <= 0 {
+		return math.NaN()
+	}
+	rank := q * observations
+	b := sort.Search(len(buckets)-1, func(i int) bool { return buckets[i].Count >= rank })
+
+	if b == len(buckets)-1 {
+		return buckets[len(buckets)-2].UpperBound
+	}
+	if b == 0 && buckets[0].UpperBound <= 0 {
+		return buckets[0].UpperBound
+	}
+
+	var (
+		bucketStart float64
+		bucketEnd   = buckets[b].UpperBound
+		count       = buckets[b].Count
+	)
+	if b > 0 {
+		bucketStart = buckets[b-1].UpperBound
+		count -= buckets[b-1].Count
+		rank -= buckets[b-1].Count
+	}
+	if count == 0 {
+		return bucketStart
+	}
+	return bucketStart + (bucketEnd-bucketStart)*(rank/count)
+}
+
+// ensureMonotonic clamps non-monotonic cumulative counts (scrape races between bucket lines),
+// the same way PromQL does before interpolation.
+func ensureMonotonic(buckets []promtext.Bucket) []promtext.Bucket {
+	out := make([]promtext.Bucket, len(buckets))
+	copy(out, buckets)
+	maxCount := math.Inf(-1)
+	for i := range out {
+		if out[i].Count > maxCount {
+			maxCount = out[i].Count
+		} else {
+			out[i].Count = maxCount
+		}
+	}
+	return out
+}
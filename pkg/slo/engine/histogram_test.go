@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/yeongki/my-operator/pkg/slo/fetch"
+	"github.com/yeongki/my-operator/pkg/slo/spec"
+	"github.com/yeongki/my-operator/pkg/slo/summary"
+)
+
+func TestBucketQuantileInterpolates(t *testing.T) {
+	start := map[string]float64{
+		`lat_seconds_bucket{le="0.1",result="success"}`:  10,
+		`lat_seconds_bucket{le="1",result="success"}`:    10,
+		`lat_seconds_bucket{le="+Inf",result="success"}`: 10,
+	}
+	end := map[string]float64{
+		`lat_seconds_bucket{le="0.1",result="success"}`:  60,
+		`lat_seconds_bucket{le="1",result="success"}`:    105,
+		`lat_seconds_bucket{le="+Inf",result="success"}`: 110,
+		`lat_seconds_bucket{le="0.1",result="error"}`:    5,
+		`lat_seconds_bucket{le="1",result="error"}`:      5,
+		`lat_seconds_bucket{le="+Inf",result="error"}`:   5,
+	}
+
+	s := spec.SLISpec{
+		ID:      "lat",
+		Inputs:  []spec.MetricRef{spec.PromMetric("lat_seconds", spec.Labels{"result": "success"})},
+		Compute: spec.ComputeSpec{Mode: spec.ComputeHistogramQuantile},
+		Judge: &spec.JudgeSpec{Rules: []spec.Rule{
+			{Metric: "p99", Op: spec.OpGT, Target: 0.5, Level: spec.LevelFail},
+		}},
+	}
+	res := evalSLI(s, testWindow(start, end))
+
+	// window: 50 obs <= 0.1, 45 obs in (0.1, 1], 5 obs > 1 => 100 total
+	want := map[string]float64{
+		"count": 100,
+		"p50":   0.1,
+		"p90":   0.1 + 0.9*(40.0/45.0),
+		"p99":   1,
+	}
+	for k, v := range want {
+		if math.Abs(res.Fields[k]-v) > 1e-9 {
+			t.Fatalf("expected %s=%v, got %v (fields=%v)", k, v, res.Fields[k], res.Fields)
+		}
+	}
+	if res.Status != summary.StatusFail {
+		t.Fatalf("expected p99 rule to fail, got %s (%s)", res.Status, res.Reason)
+	}
+}
+
+func TestBucketQuantileCorrectsResetPerSeries(t *testing.T) {
+	// process restart in the middle of the window: every bucket series drops to its new value
+	start := map[string]float64{`lat_seconds_bucket{le="1"}`: 40, `lat_seconds_bucket{le="+Inf"}`: 50}
+	mid := map[string]float64{`lat_seconds_bucket{le="1"}`: 2, `lat_seconds_bucket{le="+Inf"}`: 2}
+	end := map[string]float64{`lat_seconds_bucket{le="1"}`: 6, `lat_seconds_bucket{le="+Inf"}`: 10}
+
+	s := spec.SLISpec{
+		ID:      "lat",
+		Inputs:  []spec.MetricRef{spec.PromMetric("lat_seconds", nil)},
+		Compute: spec.ComputeSpec{Mode: spec.ComputeHistogramQuantile, Quantiles: []float64{0.5}},
+	}
+	res := evalSLI(s, testWindow(start, mid, end))
+	if res.Status != summary.StatusPass {
+		t.Fatalf("expected pass, got %s (%s)", res.Status, res.Reason)
+	}
+	if res.CounterResets != 2 || res.Fields["count"] != 10 {
+		t.Fatalf("expected 2 resets and 10 observations, got %d / %v", res.CounterResets, res.Fields["count"])
+	}
+}
+
+func TestBucketQuantileWithoutObservationsHasInsufficientData(t *testing.T) {
+	values := map[string]float64{
+		`lat_seconds_bucket{le="1"}`:    3,
+		`lat_seconds_bucket{le="+Inf"}`: 3,
+	}
+	s := spec.SLISpec{
+		ID:      "lat",
+		Inputs:  []spec.MetricRef{spec.PromMetric("lat_seconds", nil)},
+		Compute: spec.ComputeSpec{Mode: spec.ComputeHistogramQuantile},
+	}
+	res := evalSLI(s, testWindow(values, values))
+	if res.Status != summary.StatusInsufficientData || res.ReasonCode != summary.ReasonNoObservations {
+		t.Fatalf("expected insufficient data, got %s (%s)", res.Status, res.ReasonCode)
+	}
+}
+
+// testWindow builds a window from snapshots one second apart (first=start, last=end).
+func testWindow(values ...map[string]float64) window {
+	base := time.Now()
+	samples := make([]fetch.Sample, 0, len(values))
+	for i, v := range values {
+		samples = append(samples, fetch.Sample{At: base.Add(time.Duration(i) * time.Second), Values: v})
+	}
+	return newWindow(samples[0], samples[len(samples)-1], samples[1:len(samples)-1])
+}
+
+func TestHistogramQuantilePointsAtExemplar(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+	parse := func(body string, at time.Time) fetch.Sample {
+		s, err := fetch.ParseSample([]byte(body), at)
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		return s
+	}
+	start := parse(`# TYPE lat_seconds histogram
+lat_seconds_bucket{le="0.1"} 10
+lat_seconds_bucket{le="1"} 10 # {trace_id="old"} 0.9 1699999000
+lat_seconds_bucket{le="+Inf"} 10
+# EOF
+`, base)
+	end := parse(`# TYPE lat_seconds histogram
+lat_seconds_bucket{le="0.1"} 60 # {trace_id="fast"} 0.05 1700000010
+lat_seconds_bucket{le="1"} 100 # {trace_id="slow"} 0.8 1700000020
+lat_seconds_bucket{le="+Inf"} 100
+# EOF
+`, base.Add(time.Minute))
+
+	s := spec.SLISpec{
+		ID:      "lat",
+		Inputs:  []spec.MetricRef{spec.PromMetric("lat_seconds", nil)},
+		Compute: spec.ComputeSpec{Mode: spec.ComputeHistogramQuantile, Quantiles: []float64{0.5, 0.99}},
+	}
+	res := evalSLI(s, newWindow(start, end, nil))
+
+	if len(res.Exemplars) != 2 {
+		t.Fatalf("expected one exemplar per quantile, got %+v", res.Exemplars)
+	}
+	p50, p99 := res.Exemplars[0], res.Exemplars[1]
+	if p50.Field != "p50" || p50.Labels["trace_id"] != "fast" {
+		t.Fatalf("unexpected p50 exemplar %+v", p50)
+	}
+	if p99.Field != "p99" || p99.Labels["trace_id"] != "slow" || p99.Value != 0.8 ||
+		p99.Series != `lat_seconds_bucket{le="1"}` || p99.Timestamp == nil {
+		t.Fatalf("unexpected p99 exemplar %+v", p99)
+	}
+
+	// an exemplar left over from before the window is not reported
+	end.Exemplars[`lat_seconds_bucket{le="1"}`] = start.Exemplars[`lat_seconds_bucket{le="1"}`]
+	res = evalSLI(s, newWindow(start, end, nil))
+	if len(res.Exemplars) != 1 || res.Exemplars[0].Field != "p50" {
+		t.Fatalf("expected only the p50 exemplar, got %+v", res.Exemplars)
+	}
+}
@@ -0,0 +1,171 @@
+package engine
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/yeongki/my-operator/pkg/slo/common/promkey"
+	"github.com/yeongki/my-operator/pkg/slo/spec"
+)
+
+// input resolves a MetricRef against snapshots: either one exact key,
+// or every series matched by a selector combined with an aggregation.
+//
+// A key without labels (e.g. "workqueue_depth") keeps the v3 flat-map convenience and sums
+// every series of the metric name, like the selector of the same name.
+type input struct {
+	ref spec.MetricRef
+	sel *promkey.Selector // nil for exact keys
+}
+
+// newInput expects a validated ref (SLISpec.Validate), so selector parse errors cannot happen.
+func newInput(ref spec.MetricRef) input {
+	in := input{ref: ref}
+	switch {
+	case ref.Selector != "":
+		if sel, err := promkey.ParseSelector(ref.Selector); err == nil {
+			in.sel = &sel
+		}
+	case ref.Key != "" && !strings.Contains(ref.Key, "{"):
+		in.sel = &promkey.Selector{Name: ref.Key}
+	}
+	return in
+}
+
+func (in input) String() string { return in.ref.String() }
+
+// series returns the keys of values the input reads, sorted.
+func (in input) series(values map[string]float64) []string {
+	if in.sel == nil {
+		if _, ok := values[in.ref.Key]; ok {
+			return []string{in.ref.Key}
+		}
+		return nil
+	}
+	matched := promkey.Select(values, *in.sel)
+	keep := in.instanceFilter(values)
+	out := make([]string, 0, len(matched))
+	for k := range matched {
+		if keep != nil {
+			if _, labels, err := promkey.Parse(k); err != nil || !keep(labels) {
+				continue
+			}
+		}
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// instanceFilter returns nil when the input reads every instance, or a label check that only
+// keeps series of the leader of this snapshot (no leader: nothing is kept).
+func (in input) instanceFilter(values map[string]float64) func(labels map[string]string) bool {
+	if !in.ref.Leader {
+		return nil
+	}
+	leader := leaderInstance(values)
+	return func(labels map[string]string) bool {
+		return leader != "" && labels[promkey.InstanceLabel] == leader
+	}
+}
+
+// leaderMetric is exposed by controller-runtime on every replica: 1 on the leader, 0 elsewhere.
+const leaderMetric = "leader_election_master_status"
+
+// leaderInstance returns the instance reporting leadership in values, or "" when there is none
+// or several instances claim it (e.g. a snapshot taken during a handover).
+func leaderInstance(values map[string]float64) string {
+	leader := ""
+	for k, v := range values {
+		if v != 1 || !strings.HasPrefix(k, leaderMetric+"{") {
+			continue
+		}
+		name, labels, err := promkey.Parse(k)
+		if err != nil || name != leaderMetric || labels[promkey.InstanceLabel] == "" {
+			continue
+		}
+		if leader != "" && leader != labels[promkey.InstanceLabel] {
+			return ""
+		}
+		leader = labels[promkey.InstanceLabel]
+	}
+	return leader
+}
+
+// value returns the (aggregated) value of the input in one snapshot.
+// It reports false when no series is present.
+func (in input) value(values map[string]float64) (float64, bool) {
+	keys := in.series(values)
+	if len(keys) == 0 {
+		return 0, false
+	}
+	vals := make([]float64, 0, len(keys))
+	for _, k := range keys {
+		vals = append(vals, values[k])
+	}
+	return in.ref.Agg.Apply(vals), true
+}
+
+// increase returns the reset-corrected increase of the input over the window.
+// Exact keys must exist at start and end. Selected series are reset-corrected one by one
+// and then aggregated (PromQL: sum(increase(sel[window]))); a series missing at start was
+// created during the window and counts from 0. It reports false when nothing matched at end.
+// A leader-pinned input follows the leader of the end snapshot through the whole window.
+func (in input) increase(w window) (inc float64, resets int, ok bool) {
+	if in.sel == nil {
+		_, okA := w.start()[in.ref.Key]
+		_, okB := w.end()[in.ref.Key]
+		if !okA || !okB {
+			return 0, 0, false
+		}
+		inc, resets = increase(w.points(in.ref.Key))
+		return inc, resets, true
+	}
+
+	keys := in.series(w.end())
+	if len(keys) == 0 {
+		return 0, 0, false
+	}
+	start := w.start()
+	incs := make([]float64, 0, len(keys))
+	for _, k := range keys {
+		pts := w.points(k)
+		if _, ok := start[k]; !ok {
+			pts = append([]float64{0}, pts...)
+		}
+		v, r := increase(pts)
+		incs = append(incs, v)
+		resets += r
+	}
+	return in.ref.Agg.Apply(incs), resets, true
+}
+
+// sumPoints returns, per sample, the sum of the input values over the samples where all inputs are present.
+func sumPoints(w window, inputs []input) []float64 {
+	out := make([]float64, 0, len(w.samples))
+	for _, s := range w.samples {
+		sum, ok := 0.0, true
+		for _, in := range inputs {
+			v, present := in.value(s.Values)
+			if !present {
+				ok = false
+				break
+			}
+			sum += v
+		}
+		if ok {
+			out = append(out, sum)
+		}
+	}
+	return out
+}
+
+// seen reports whether the input is present in at least one sample.
+func (in input) seen(w window) bool {
+	for _, s := range w.samples {
+		if _, ok := in.value(s.Values); ok {
+			return true
+		}
+	}
+	return false
+}
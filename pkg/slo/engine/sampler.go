@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/yeongki/my-operator/pkg/slo"
+	"github.com/yeongki/my-operator/pkg/slo/fetch"
+)
+
+// Sampler scrapes a fetcher periodically in the background between Start and Stop.
+// The collected samples are passed to ExecuteRequest.Samples, so window modes
+// (max/min/avg/last) and counter reset detection see what happened inside the window.
+//
+// A failed scrape is logged and skipped: "measurement failure is not test failure".
+type Sampler struct {
+	fetcher  fetch.MetricsFetcher
+	interval time.Duration
+	logf     func(string, ...any)
+
+	mu       sync.Mutex
+	samples  []fetch.Sample
+	failures int
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewSampler creates a sampler. interval must be > 0. l may be nil.
+func NewSampler(fetcher fetch.MetricsFetcher, interval time.Duration, l slo.Logger) *Sampler {
+	return &Sampler{
+		fetcher:  fetcher,
+		interval: interval,
+		logf:     slo.NewLogger(l).Logf,
+	}
+}
+
+// Start begins sampling. The first scrape happens one interval after Start,
+// the window edges themselves are fetched by Engine.Execute.
+func (s *Sampler) Start(ctx context.Context) {
+	if s.done != nil || s.interval <= 0 {
+		return
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case at := <-ticker.C:
+				s.scrape(ctx, at)
+			}
+		}
+	}()
+}
+
+// Stop ends sampling, waits for an in-flight scrape and returns the samples ordered by time.
+// It is safe to call Stop without Start.
+func (s *Sampler) Stop() []fetch.Sample {
+	if s.done != nil {
+		s.cancel()
+		<-s.done
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]fetch.Sample, len(s.samples))
+	copy(out, s.samples)
+	return out
+}
+
+// Failures returns how many scrapes failed so far.
+func (s *Sampler) Failures() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.failures
+}
+
+func (s *Sampler) scrape(ctx context.Context, at time.Time) {
+	sample, err := s.fetcher.Fetch(ctx, at)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		if ctx.Err() != nil {
+			// stopped while scraping: not a measurement failure
+			return
+		}
+		s.failures++
+		s.logf("slo sampler: fetch failed (skip): %v", err)
+		return
+	}
+	s.samples = append(s.samples, sample)
+}
@@ -3,6 +3,7 @@ package engine
 import (
 	"time"
 
+	"github.com/yeongki/my-operator/pkg/slo/fetch"
 	"github.com/yeongki/my-operator/pkg/slo/spec"
 )
 
@@ -26,6 +27,14 @@ type ExecuteRequest struct {
 	Config  RunConfig
 	Specs   []spec.SLISpec // core input: 직접 주입
 	OutPath string
+
+	// Samples are optional intermediate snapshots taken inside the window (e.g. by a Sampler or
+	// a range fetch). Delta computations use them to detect counter resets between start and end;
+	// without them, only the start and end snapshots are seen.
+	Samples []fetch.Sample
+
+	// Warnings are carried into Summary.Warnings (e.g. sampler or session notes).
+	Warnings []string
 	// 호환성/편의용: 레지스트리를 쓰는 호출자를 위해 남길 수 있음, 일단 주석처리함.
 	// SLIIDs  []string
 }
@@ -6,9 +6,11 @@ type MeasurementMethod string
 const (
 	InsideSnapshot MeasurementMethod = "InsideSnapshot"
 
+	// OutsideSnapshot scrapes /metrics directly from the test process (fetch.HTTPFetcher).
+	OutsideSnapshot MeasurementMethod = "OutsideSnapshot"
+
 	// Reserved for later phases.
 	InsideAnnotation MeasurementMethod = "InsideAnnotation"
-	OutsideSnapshot  MeasurementMethod = "OutsideSnapshot"
 )
 
 // RunLocation describes where the measurement runs.
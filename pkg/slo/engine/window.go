@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"math"
+	"sort"
+
+	"github.com/yeongki/my-operator/pkg/slo/fetch"
+	"github.com/yeongki/my-operator/pkg/slo/spec"
+)
+
+// window is the ordered set of snapshots of one measurement window.
+// samples[0] is the start snapshot and samples[len-1] the end snapshot;
+// anything in between is an optional intermediate sample.
+type window struct {
+	samples []fetch.Sample
+}
+
+// newWindow orders intermediate samples by time and drops those outside (start.At, end.At).
+func newWindow(start, end fetch.Sample, intermediate []fetch.Sample) window {
+	mid := make([]fetch.Sample, 0, len(intermediate))
+	for _, s := range intermediate {
+		if s.Values == nil || !s.At.After(start.At) || !s.At.Before(end.At) {
+			continue
+		}
+		mid = append(mid, s)
+	}
+	sort.SliceStable(mid, func(i, j int) bool { return mid[i].At.Before(mid[j].At) })
+
+	samples := make([]fetch.Sample, 0, len(mid)+2)
+	samples = append(samples, start)
+	samples = append(samples, mid...)
+	samples = append(samples, end)
+	return window{samples: samples}
+}
+
+func (w window) start() map[string]float64 { return w.samples[0].Values }
+
+func (w window) end() map[string]float64 { return w.samples[len(w.samples)-1].Values }
+
+// points returns the values of key over the window, skipping samples where key is absent.
+func (w window) points(key string) []float64 {
+	out := make([]float64, 0, len(w.samples))
+	for _, s := range w.samples {
+		if v, ok := s.Values[key]; ok {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// overWindow reduces points with a window mode (max/min/avg/last).
+func overWindow(mode spec.ComputeMode, points []float64) float64 {
+	out := points[0]
+	switch mode {
+	case spec.ComputeMax:
+		for _, p := range points[1:] {
+			out = math.Max(out, p)
+		}
+	case spec.ComputeMin:
+		for _, p := range points[1:] {
+			out = math.Min(out, p)
+		}
+	case spec.ComputeAvg:
+		for _, p := range points[1:] {
+			out += p
+		}
+		out /= float64(len(points))
+	case spec.ComputeLast:
+		out = points[len(points)-1]
+	}
+	return out
+}
+
+func isWindowMode(mode spec.ComputeMode) bool {
+	switch mode {
+	case spec.ComputeMax, spec.ComputeMin, spec.ComputeAvg, spec.ComputeLast:
+		return true
+	default:
+		return false
+	}
+}
+
+// increase mirrors PromQL increase() over consecutive points (without extrapolation,
+// since the window edges are the actual snapshots): a drop between two points is a
+// counter reset, after which the new value counts from 0.
+// It returns the reset-corrected increase and the number of detected resets.
+func increase(points []float64) (inc float64, resets int) {
+	for i := 1; i < len(points); i++ {
+		prev, cur := points[i-1], points[i]
+		if cur < prev {
+			resets++
+			inc += cur
+			continue
+		}
+		inc += cur - prev
+	}
+	return inc, resets
+}
@@ -1,14 +1,27 @@
 package fetch
 
 import (
+	"bytes"
 	"context"
+	"io"
+	"mime"
+	"strings"
 	"time"
+
+	"github.com/yeongki/my-operator/pkg/slo/fetch/promtext"
 )
 
 // Sample is one snapshot at a point in time.
+// Timestamps and Exemplars are keyed like Values and only hold series that expose one;
+// both are nil for fetchers that do not see them (e.g. Prometheus API queries).
 type Sample struct {
 	At     time.Time
 	Values map[string]float64 // metricKey -> value
+
+	// Timestamps is the exposed sample time, e.g. when a collector last updated the series.
+	Timestamps map[string]time.Time
+	// Exemplars holds OpenMetrics exemplars, e.g. the trace of the latest observation of a bucket.
+	Exemplars map[string]promtext.Exemplar
 }
 
 // MetricsFetcher fetches one snapshot. Implementations decide how to obtain it.
@@ -18,3 +31,83 @@ type Sample struct {
 type MetricsFetcher interface {
 	Fetch(ctx context.Context, at time.Time) (Sample, error)
 }
+
+// Scraper returns one raw Prometheus exposition body (text, OpenMetrics or protobuf) and its
+// Content-Type ("" when the transport does not carry one, e.g. curl pod logs).
+// Fetchers backed by a /metrics endpoint implement it so bodies can be recorded (see RecordingFetcher).
+type Scraper interface {
+	Scrape(ctx context.Context) (body []byte, contentType string, err error)
+}
+
+// ParseSample parses an exposition body of unknown format into a Sample taken at at.
+// The format is guessed from the body: delimited protobuf (see IsProtobuf) is parsed with
+// ParseProtobuf, OpenMetrics (ending with "# EOF") with promtext.ParseOpenMetrics, anything
+// else as the classic text format. Prefer ParseSampleType when the Content-Type is known.
+func ParseSample(body []byte, at time.Time) (Sample, error) {
+	parse := promtext.ParseText
+	switch {
+	case IsProtobuf(body):
+		parse = ParseProtobuf
+	case promtext.IsOpenMetrics(body):
+		parse = promtext.ParseOpenMetrics
+	}
+	return parseSample(parse, body, at)
+}
+
+// ParseSampleType parses body in the format named by contentType, the negotiated Content-Type
+// of the scrape response. Without a Content-Type, or with one that names no exposition format
+// (e.g. application/octet-stream from a proxy), it falls back to ParseSample.
+func ParseSampleType(body []byte, contentType string, at time.Time) (Sample, error) {
+	parse := formatParser(contentType)
+	if parse == nil {
+		return ParseSample(body, at)
+	}
+	return parseSample(parse, body, at)
+}
+
+// formatParser returns the parser of an exposition media type, or nil when it names none.
+func formatParser(contentType string) func(io.Reader) (*promtext.Result, error) {
+	if strings.TrimSpace(contentType) == "" {
+		return nil
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+	switch mediaType {
+	case contentTypeProtobufBase:
+		if params["proto"] == protobufProto && params["encoding"] == "delimited" {
+			return ParseProtobuf
+		}
+		return nil
+	case promtext.ContentTypeOpenMetrics:
+		// ParseOpenMetrics requires "# EOF": a truncated response is an error, not a text body
+		return promtext.ParseOpenMetrics
+	case "text/plain":
+		return promtext.ParseText
+	default:
+		return nil
+	}
+}
+
+func parseSample(parse func(io.Reader) (*promtext.Result, error), body []byte, at time.Time) (Sample, error) {
+	res, err := parse(bytes.NewReader(body))
+	if err != nil {
+		// the same body parses the same way again: do not retry it
+		return Sample{}, Permanent(err)
+	}
+	s := Sample{At: at, Values: res.Values}
+	if len(res.Timestamps) > 0 {
+		s.Timestamps = res.Timestamps
+	}
+	if len(res.Exemplars) > 0 {
+		s.Exemplars = res.Exemplars
+	}
+	return s, nil
+}
+
+// RangeFetcher returns the samples of a whole window at once (e.g. Prometheus range queries).
+// Sessions use it instead of a background sampler.
+type RangeFetcher interface {
+	FetchRange(ctx context.Context, start, end time.Time, step time.Duration) ([]Sample, error)
+}
@@ -0,0 +1,205 @@
+package fetch
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yeongki/my-operator/pkg/slo/fetch/promtext"
+)
+
+const (
+	defaultHTTPTimeout    = 10 * time.Second
+	defaultHTTPRetryDelay = time.Second
+
+	// maxErrorBody limits how much of a non-2xx response ends up in the error message.
+	maxErrorBody = 512
+)
+
+// HTTPConfig configures HTTPFetcher.
+type HTTPConfig struct {
+	// URL of the /metrics endpoint, e.g. https://localhost:8443/metrics.
+	URL string
+
+	// BearerToken is sent as "Authorization: Bearer <token>".
+	// BearerTokenFile is read on every scrape instead (projected tokens rotate).
+	BearerToken     string
+	BearerTokenFile string
+
+	// CAFile is a PEM bundle used to verify the server (default: system roots).
+	// ServerName overrides the name checked against the certificate, e.g. the service
+	// DNS name when scraping through a port-forward on 127.0.0.1.
+	CAFile             string
+	ServerName         string
+	InsecureSkipVerify bool
+
+	// Timeout bounds one attempt (default 10s).
+	Timeout time.Duration
+	// Retries is the number of extra attempts after a failed one (default 0).
+	// 4xx responses are not retried: a wrong token or path does not heal by itself.
+	// Keep it 0 when the fetcher is wrapped in a RetryFetcher (one retry layer).
+	Retries    int
+	RetryDelay time.Duration // default 1s
+
+	// OpenMetrics asks for application/openmetrics-text (falling back to the classic text
+	// format if the endpoint does not offer it). The response is parsed by its Content-Type.
+	OpenMetrics bool
+	// Protobuf asks for the delimited protobuf format first (ContentTypeProtobuf), which is
+	// cheaper to parse for large expositions. Text formats stay acceptable as fallback.
+	Protobuf bool
+
+	// Client overrides the HTTP client built from the TLS options (tests).
+	Client *http.Client
+}
+
+// HTTPFetcher scrapes a Prometheus /metrics endpoint directly over HTTP(S).
+type HTTPFetcher struct {
+	cfg    HTTPConfig
+	client *http.Client
+}
+
+// NewHTTPFetcher validates cfg and builds the HTTP client.
+func NewHTTPFetcher(cfg HTTPConfig) (*HTTPFetcher, error) {
+	if strings.TrimSpace(cfg.URL) == "" {
+		return nil, errors.New("http fetcher: URL is required")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultHTTPTimeout
+	}
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = defaultHTTPRetryDelay
+	}
+	if cfg.Retries < 0 {
+		cfg.Retries = 0
+	}
+
+	client := cfg.Client
+	if client == nil {
+		// InsecureSkipVerify is opt-in for self-signed test endpoints
+		tlsCfg := &tls.Config{ServerName: cfg.ServerName, InsecureSkipVerify: cfg.InsecureSkipVerify}
+		if cfg.CAFile != "" {
+			pem, err := os.ReadFile(cfg.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("http fetcher: read CA file: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("http fetcher: no certificates in CA file %s", cfg.CAFile)
+			}
+			tlsCfg.RootCAs = pool
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsCfg
+		client = &http.Client{Transport: transport}
+	}
+	return &HTTPFetcher{cfg: cfg, client: client}, nil
+}
+
+// Fetch scrapes the endpoint (with retries) and parses the body in the format of the
+// response Content-Type (see ParseSampleType).
+func (f *HTTPFetcher) Fetch(ctx context.Context, at time.Time) (Sample, error) {
+	body, contentType, err := f.Scrape(ctx)
+	if err != nil {
+		return Sample{}, err
+	}
+	sample, err := ParseSampleType(body, contentType, at)
+	if err != nil {
+		return Sample{}, fmt.Errorf("parse %s: %w", f.cfg.URL, err)
+	}
+	return sample, nil
+}
+
+// Scrape returns the raw body of the endpoint and its Content-Type (with retries).
+func (f *HTTPFetcher) Scrape(ctx context.Context) ([]byte, string, error) {
+	var lastErr error
+	for attempt := 0; attempt <= f.cfg.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, "", fmt.Errorf("%w (last error: %v)", ctx.Err(), lastErr)
+			case <-time.After(f.cfg.RetryDelay):
+			}
+		}
+		body, contentType, retry, err := f.scrapeOnce(ctx)
+		if err == nil {
+			return body, contentType, nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+	return nil, "", lastErr
+}
+
+// scrapeOnce performs one attempt and reports whether a failure is worth retrying.
+func (f *HTTPFetcher) scrapeOnce(ctx context.Context) (body []byte, contentType string, retry bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, f.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.cfg.URL, nil)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("http fetcher: %w", err)
+	}
+	req.Header.Set("Accept", f.accept())
+	token, err := f.token()
+	if err != nil {
+		return nil, "", false, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, "", true, fmt.Errorf("scrape %s: %w", f.cfg.URL, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		err := &StatusError{
+			URL:    f.cfg.URL,
+			Code:   resp.StatusCode,
+			Status: resp.Status,
+			Body:   strings.TrimSpace(string(snippet)),
+		}
+		return nil, "", IsRetryable(err), err
+	}
+
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", true, fmt.Errorf("scrape %s: read body: %w", f.cfg.URL, err)
+	}
+	return body, resp.Header.Get("Content-Type"), false, nil
+}
+
+func (f *HTTPFetcher) accept() string {
+	var parts []string
+	if f.cfg.Protobuf {
+		parts = append(parts, ContentTypeProtobuf+";q=1")
+	}
+	if f.cfg.OpenMetrics {
+		parts = append(parts, promtext.ContentTypeOpenMetrics+";version=1.0.0;q=0.9")
+	}
+	parts = append(parts, "text/plain;version=0.0.4;q=0.5", "*/*;q=0.1")
+	return strings.Join(parts, ",")
+}
+
+func (f *HTTPFetcher) token() (string, error) {
+	if f.cfg.BearerTokenFile == "" {
+		return f.cfg.BearerToken, nil
+	}
+	b, err := os.ReadFile(f.cfg.BearerTokenFile)
+	if err != nil {
+		return "", fmt.Errorf("http fetcher: read token file: %w", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
@@ -0,0 +1,139 @@
+package fetch
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testExposition = `# TYPE workqueue_depth gauge
+workqueue_depth{name="joboperator"} 3
+`
+
+func TestHTTPFetcherSendsTokenAndRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(testExposition))
+	}))
+	defer srv.Close()
+
+	f, err := NewHTTPFetcher(HTTPConfig{URL: srv.URL, BearerToken: "secret", Retries: 1, RetryDelay: time.Millisecond})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	at := time.Now()
+	sample, err := f.Fetch(context.Background(), at)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !sample.At.Equal(at) || sample.Values[`workqueue_depth{name="joboperator"}`] != 3 {
+		t.Fatalf("unexpected sample %+v", sample)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected 2 attempts, got %d", calls.Load())
+	}
+}
+
+func TestHTTPFetcherDoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	f, _ := NewHTTPFetcher(HTTPConfig{URL: srv.URL, Retries: 3, RetryDelay: time.Millisecond})
+	_, err := f.Fetch(context.Background(), time.Now())
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("expected 403 error, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected a single attempt, got %d", calls.Load())
+	}
+}
+
+func TestHTTPFetcherRequestsOpenMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Accept"), "application/openmetrics-text") {
+			_, _ = w.Write([]byte(testExposition))
+			return
+		}
+		w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+		_, _ = w.Write([]byte("# TYPE workqueue_depth gauge\n" +
+			"workqueue_depth{name=\"joboperator\"} 7 # {trace_id=\"t\"} 1\n# EOF\n"))
+	}))
+	defer srv.Close()
+
+	f, _ := NewHTTPFetcher(HTTPConfig{URL: srv.URL, OpenMetrics: true})
+	sample, err := f.Fetch(context.Background(), time.Now())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if sample.Values[`workqueue_depth{name="joboperator"}`] != 7 {
+		t.Fatalf("expected the OpenMetrics body to be parsed, got %+v", sample.Values)
+	}
+}
+
+func TestHTTPFetcherRejectsTruncatedOpenMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+		// cut before "# EOF": as classic text, _total and _created would become plain series
+		_, _ = w.Write([]byte("# TYPE reconcile counter\nreconcile_total 7\nreconcile_created 1.7e9\n"))
+	}))
+	defer srv.Close()
+
+	f, _ := NewHTTPFetcher(HTTPConfig{URL: srv.URL, OpenMetrics: true})
+	body, contentType, err := f.Scrape(context.Background())
+	if err != nil || len(body) == 0 || !strings.HasPrefix(contentType, "application/openmetrics-text") {
+		t.Fatalf("expected the raw body and its Content-Type, got %q, %v", contentType, err)
+	}
+	if _, err := f.Fetch(context.Background(), time.Now()); err == nil || !strings.Contains(err.Error(), "# EOF") {
+		t.Fatalf("expected a missing # EOF to fail the scrape, got %v", err)
+	}
+}
+
+func TestHTTPFetcherTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(testExposition))
+	}))
+	defer srv.Close()
+
+	// untrusted self-signed certificate
+	f, _ := NewHTTPFetcher(HTTPConfig{URL: srv.URL})
+	if _, err := f.Fetch(context.Background(), time.Now()); err == nil {
+		t.Fatalf("expected certificate error")
+	}
+
+	f, _ = NewHTTPFetcher(HTTPConfig{URL: srv.URL, InsecureSkipVerify: true})
+	if _, err := f.Fetch(context.Background(), time.Now()); err != nil {
+		t.Fatalf("expected insecure scrape to work, got %v", err)
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatalf("write CA file: %v", err)
+	}
+	f, err := NewHTTPFetcher(HTTPConfig{URL: srv.URL, CAFile: caFile})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := f.Fetch(context.Background(), time.Now()); err != nil {
+		t.Fatalf("expected CA file to verify the server, got %v", err)
+	}
+}
@@ -0,0 +1,292 @@
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yeongki/my-operator/pkg/slo/common/promkey"
+)
+
+// DefaultTargetLabels are the target labels Prometheus (kube-prometheus service discovery)
+// attaches to scraped series. They are dropped so API results use the same keys as a direct
+// /metrics scrape, and `key:` inputs match either way.
+//
+// A query opts in to a dropped label by matching on it: the results of
+// reconcile_total{namespace="team-a"} keep namespace, so selectors can read it.
+var DefaultTargetLabels = []string{"job", "instance", "namespace", "pod", "service", "endpoint", "container"}
+
+// PromAPIConfig configures PromAPIFetcher.
+type PromAPIConfig struct {
+	// URL is the Prometheus base URL, e.g. http://prometheus-operated.monitoring:9090.
+	URL string
+	// Queries are the series selectors evaluated on every fetch (see spec.QuerySelectors).
+	Queries []string
+
+	BearerToken string
+	Timeout     time.Duration // per query, default 10s
+	Client      *http.Client
+
+	// DropLabels overrides DefaultTargetLabels. Series that only differ by a dropped label
+	// (e.g. several replicas) are summed.
+	DropLabels []string
+}
+
+// PromAPIFetcher computes snapshots from an existing Prometheus through /api/v1/query.
+// Prometheus returns the latest sample within its lookback window, so a snapshot at
+// FinishedAt may lag the operator by up to one scrape interval.
+type PromAPIFetcher struct {
+	cfg    PromAPIConfig
+	client *http.Client
+	drop   map[string]bool
+}
+
+// NewPromAPIFetcher validates cfg.
+func NewPromAPIFetcher(cfg PromAPIConfig) (*PromAPIFetcher, error) {
+	if strings.TrimSpace(cfg.URL) == "" {
+		return nil, errors.New("prometheus fetcher: URL is required")
+	}
+	if len(cfg.Queries) == 0 {
+		return nil, errors.New("prometheus fetcher: no queries")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultHTTPTimeout
+	}
+	client := cfg.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	dropLabels := cfg.DropLabels
+	if dropLabels == nil {
+		dropLabels = DefaultTargetLabels
+	}
+	drop := map[string]bool{}
+	for _, l := range dropLabels {
+		drop[l] = true
+	}
+	return &PromAPIFetcher{cfg: cfg, client: client, drop: drop}, nil
+}
+
+// Fetch evaluates every query as an instant query at at.
+func (f *PromAPIFetcher) Fetch(ctx context.Context, at time.Time) (Sample, error) {
+	values := map[string]float64{}
+	for _, q := range f.cfg.Queries {
+		params := url.Values{"query": {q}, "time": {formatPromTime(at)}}
+		var data promData
+		if err := f.get(ctx, "/api/v1/query", params, &data); err != nil {
+			return Sample{}, err
+		}
+		if data.ResultType != "vector" {
+			return Sample{}, Permanent(fmt.Errorf("prometheus query %q: unexpected result type %q", q, data.ResultType))
+		}
+		keep := matchedLabels(q)
+		for _, r := range data.Result {
+			v, err := parsePromValue(r.Value)
+			if err != nil {
+				return Sample{}, Permanent(fmt.Errorf("prometheus query %q: %w", q, err))
+			}
+			values[f.key(r.Metric, keep)] += v
+		}
+	}
+	return Sample{At: at, Values: values}, nil
+}
+
+// FetchRange evaluates every query over [start, end] with step (/api/v1/query_range)
+// and returns one sample per evaluation timestamp, e.g. as ExecuteRequest.Samples.
+func (f *PromAPIFetcher) FetchRange(ctx context.Context, start, end time.Time, step time.Duration) ([]Sample, error) {
+	if step <= 0 {
+		return nil, errors.New("prometheus fetcher: step must be > 0")
+	}
+	byTime := map[float64]map[string]float64{}
+	for _, q := range f.cfg.Queries {
+		params := url.Values{
+			"query": {q},
+			"start": {formatPromTime(start)},
+			"end":   {formatPromTime(end)},
+			"step":  {strconv.FormatFloat(step.Seconds(), 'f', -1, 64)},
+		}
+		var data promData
+		if err := f.get(ctx, "/api/v1/query_range", params, &data); err != nil {
+			return nil, err
+		}
+		if data.ResultType != "matrix" {
+			return nil, Permanent(fmt.Errorf("prometheus query %q: unexpected result type %q", q, data.ResultType))
+		}
+		keep := matchedLabels(q)
+		for _, r := range data.Result {
+			key := f.key(r.Metric, keep)
+			for _, pair := range r.Values {
+				ts, err := parsePromTime(pair)
+				if err != nil {
+					return nil, Permanent(fmt.Errorf("prometheus query %q: %w", q, err))
+				}
+				v, err := parsePromValue(pair)
+				if err != nil {
+					return nil, Permanent(fmt.Errorf("prometheus query %q: %w", q, err))
+				}
+				if byTime[ts] == nil {
+					byTime[ts] = map[string]float64{}
+				}
+				byTime[ts][key] += v
+			}
+		}
+	}
+
+	out := make([]Sample, 0, len(byTime))
+	for ts, values := range byTime {
+		sec, frac := splitSeconds(ts)
+		out = append(out, Sample{At: time.Unix(sec, frac), Values: values})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].At.Before(out[j].At) })
+	return out, nil
+}
+
+// key formats a result series like a scrape key, without the dropped labels the query
+// does not match on (keep).
+func (f *PromAPIFetcher) key(metric map[string]string, keep map[string]bool) string {
+	labels := make(map[string]string, len(metric))
+	for k, v := range metric {
+		if k == "__name__" || (f.drop[k] && !keep[k]) {
+			continue
+		}
+		labels[k] = v
+	}
+	return promkey.Format(metric["__name__"], labels)
+}
+
+// matchedLabels returns the label names the selector q matches on (nil for other PromQL).
+func matchedLabels(q string) map[string]bool {
+	sel, err := promkey.ParseSelector(q)
+	if err != nil {
+		return nil
+	}
+	keep := make(map[string]bool, len(sel.Matchers))
+	for _, m := range sel.Matchers {
+		keep[m.Name] = true
+	}
+	return keep
+}
+
+// promResponse is the envelope of the Prometheus HTTP API.
+type promResponse struct {
+	Status    string          `json:"status"`
+	Data      json.RawMessage `json:"data"`
+	ErrorType string          `json:"errorType"`
+	Error     string          `json:"error"`
+}
+
+type promData struct {
+	ResultType string       `json:"resultType"`
+	Result     []promSeries `json:"result"`
+}
+
+type promSeries struct {
+	Metric map[string]string `json:"metric"`
+	Value  []any             `json:"value"`  // vector: [ts, "value"]
+	Values [][]any           `json:"values"` // matrix: [[ts, "value"], ...]
+}
+
+func (f *PromAPIFetcher) get(ctx context.Context, path string, params url.Values, into *promData) error {
+	ctx, cancel := context.WithTimeout(ctx, f.cfg.Timeout)
+	defer cancel()
+
+	endpoint := strings.TrimRight(f.cfg.URL, "/") + path + "?" + params.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("prometheus fetcher: %w", err)
+	}
+	if f.cfg.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+f.cfg.BearerToken)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("prometheus query %q: %w", params.Get("query"), err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("prometheus query %q: read body: %w", params.Get("query"), err)
+	}
+	var env promResponse
+	jsonErr := json.Unmarshal(body, &env)
+	if resp.StatusCode/100 != 2 {
+		// Prometheus answers errors with the envelope and a matching status
+		// (400 bad_data, 422 execution, 503 timeout); IsRetryable decides by the status.
+		snippet := string(body)
+		if jsonErr == nil && env.Error != "" {
+			snippet = env.ErrorType + ": " + env.Error
+		} else if len(snippet) > maxErrorBody {
+			snippet = snippet[:maxErrorBody]
+		}
+		err := &StatusError{
+			URL:    strings.TrimRight(f.cfg.URL, "/") + path,
+			Code:   resp.StatusCode,
+			Status: resp.Status,
+			Body:   snippet,
+		}
+		if env.ErrorType == "bad_data" {
+			// a malformed query never heals, whatever the status
+			return Permanent(fmt.Errorf("prometheus query %q: %w", params.Get("query"), err))
+		}
+		return fmt.Errorf("prometheus query %q: %w", params.Get("query"), err)
+	}
+	if jsonErr != nil {
+		return Permanent(fmt.Errorf("prometheus query %q: decode response: %w", params.Get("query"), jsonErr))
+	}
+	if env.Status != "success" {
+		err := fmt.Errorf("prometheus query %q: %s: %s", params.Get("query"), env.ErrorType, env.Error)
+		if env.ErrorType == "bad_data" {
+			return Permanent(err)
+		}
+		return err
+	}
+	if err := json.Unmarshal(env.Data, into); err != nil {
+		return Permanent(fmt.Errorf("prometheus query %q: decode data: %w", params.Get("query"), err))
+	}
+	return nil
+}
+
+func formatPromTime(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixNano())/1e9, 'f', 3, 64)
+}
+
+func parsePromTime(pair []any) (float64, error) {
+	if len(pair) != 2 {
+		return 0, fmt.Errorf("malformed sample %v", pair)
+	}
+	ts, ok := pair[0].(float64)
+	if !ok {
+		return 0, fmt.Errorf("malformed timestamp %v", pair[0])
+	}
+	return ts, nil
+}
+
+// parsePromValue reads the string value of a [ts, "value"] pair ("NaN", "+Inf" included).
+func parsePromValue(pair []any) (float64, error) {
+	if len(pair) != 2 {
+		return 0, fmt.Errorf("malformed sample %v", pair)
+	}
+	s, ok := pair[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("malformed value %v", pair[1])
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse value %q: %w", s, err)
+	}
+	return v, nil
+}
+
+func splitSeconds(ts float64) (sec, nsec int64) {
+	ms := int64(ts*1000 + 0.5) // the API reports millisecond precision
+	return ms / 1000, (ms % 1000) * int64(time.Millisecond)
+}
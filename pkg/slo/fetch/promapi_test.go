@@ -0,0 +1,174 @@
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakePrometheus answers /api/v1/query and /api/v1/query_range for two replicas of one counter.
+// job, instance, pod and namespace are target labels added by service discovery.
+func fakePrometheus(t *testing.T) *httptest.Server {
+	t.Helper()
+	series := func(pod string) map[string]string {
+		return map[string]string{
+			"__name__": "controller_runtime_reconcile_total", "result": "error",
+			"job": "my-operator", "instance": pod + ":8443", "pod": pod, "namespace": "team-a",
+		}
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if !strings.HasPrefix(q.Get("query"), "controller_runtime_reconcile_total{") {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"unexpected query"}`))
+			return
+		}
+		var data any
+		switch r.URL.Path {
+		case "/api/v1/query":
+			if q.Get("time") != "1700000060.000" {
+				t.Errorf("unexpected time %q", q.Get("time"))
+			}
+			data = map[string]any{"resultType": "vector", "result": []any{
+				map[string]any{"metric": series("a"), "value": []any{1700000060, "3"}},
+				map[string]any{"metric": series("b"), "value": []any{1700000060, "4"}},
+			}}
+		case "/api/v1/query_range":
+			data = map[string]any{"resultType": "matrix", "result": []any{
+				map[string]any{"metric": series("a"), "values": []any{
+					[]any{1700000000, "1"}, []any{1700000030, "2"},
+				}},
+				map[string]any{"metric": series("b"), "values": []any{
+					[]any{1700000030, "5"},
+				}},
+			}}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"status": "success", "data": data})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestPromAPIFetcherInstantQuery(t *testing.T) {
+	srv := fakePrometheus(t)
+	f, err := NewPromAPIFetcher(PromAPIConfig{
+		URL:     srv.URL,
+		Queries: []string{`controller_runtime_reconcile_total{result="error"}`},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	s, err := f.Fetch(context.Background(), time.Unix(1700000060, 0))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	// target labels dropped: the key of a direct scrape, replicas summed
+	const key = `controller_runtime_reconcile_total{result="error"}`
+	if len(s.Values) != 1 || s.Values[key] != 7 {
+		t.Fatalf("expected %s = 7, got %v", key, s.Values)
+	}
+}
+
+func TestPromAPIFetcherKeepsMatchedTargetLabels(t *testing.T) {
+	srv := fakePrometheus(t)
+	f, _ := NewPromAPIFetcher(PromAPIConfig{
+		URL:     srv.URL,
+		Queries: []string{`controller_runtime_reconcile_total{pod=~".+",result="error"}`},
+	})
+
+	s, err := f.Fetch(context.Background(), time.Unix(1700000060, 0))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	// pod is matched on, so it is kept; namespace is not
+	want := map[string]float64{
+		`controller_runtime_reconcile_total{pod="a",result="error"}`: 3,
+		`controller_runtime_reconcile_total{pod="b",result="error"}`: 4,
+	}
+	if len(s.Values) != len(want) {
+		t.Fatalf("unexpected values %v", s.Values)
+	}
+	for k, v := range want {
+		if s.Values[k] != v {
+			t.Fatalf("expected %s = %v, got %v", k, v, s.Values)
+		}
+	}
+}
+
+func TestPromAPIFetcherRangeQuery(t *testing.T) {
+	srv := fakePrometheus(t)
+	f, _ := NewPromAPIFetcher(PromAPIConfig{
+		URL:     srv.URL,
+		Queries: []string{`controller_runtime_reconcile_total{namespace="team-a",result="error"}`},
+	})
+
+	samples, err := f.FetchRange(context.Background(), time.Unix(1700000000, 0), time.Unix(1700000030, 0), 30*time.Second)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	// namespace matched on and kept, pod dropped: replicas summed
+	const key = `controller_runtime_reconcile_total{namespace="team-a",result="error"}`
+	if len(samples) != 2 || samples[0].Values[key] != 1 || samples[1].Values[key] != 7 {
+		t.Fatalf("unexpected samples %+v", samples)
+	}
+	if !samples[1].At.Equal(time.Unix(1700000030, 0)) {
+		t.Fatalf("unexpected sample time %v", samples[1].At)
+	}
+}
+
+func TestPromAPIFetcherReportsAPIErrors(t *testing.T) {
+	srv := fakePrometheus(t)
+	f, _ := NewPromAPIFetcher(PromAPIConfig{URL: srv.URL, Queries: []string{"other"}})
+	_, err := f.Fetch(context.Background(), time.Now())
+	if err == nil || !strings.Contains(err.Error(), "bad_data: unexpected query") {
+		t.Fatalf("expected API error, got %v", err)
+	}
+	var status *StatusError
+	if !errors.As(err, &status) || status.Code != http.StatusBadRequest || IsRetryable(err) {
+		t.Fatalf("expected a permanent 400 StatusError, got %#v", err)
+	}
+}
+
+func TestPromAPIFetcherClassifiesHTTPErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		code      int
+		body      string
+		retryable bool
+	}{
+		{name: "unauthorized", code: http.StatusUnauthorized, body: "Unauthorized"},
+		{name: "bad_data", code: http.StatusUnprocessableEntity,
+			body: `{"status":"error","errorType":"bad_data","error":"parse error"}`},
+		{name: "timeout", code: http.StatusServiceUnavailable, retryable: true,
+			body: `{"status":"error","errorType":"timeout","error":"query timed out"}`},
+		{name: "throttled", code: http.StatusTooManyRequests, body: "slow down", retryable: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.code)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+			f, _ := NewPromAPIFetcher(PromAPIConfig{URL: srv.URL, Queries: []string{"up"}})
+
+			_, err := f.Fetch(context.Background(), time.Now())
+			var status *StatusError
+			if !errors.As(err, &status) || status.Code != tt.code {
+				t.Fatalf("expected StatusError %d, got %v", tt.code, err)
+			}
+			if IsRetryable(err) != tt.retryable {
+				t.Fatalf("expected retryable %v for %v", tt.retryable, err)
+			}
+		})
+	}
+}
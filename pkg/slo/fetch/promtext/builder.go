@@ -0,0 +1,55 @@
+package promtext
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// Builder assembles a Result from samples decoded elsewhere, e.g. the protobuf exposition
+// format (fetch.ParseProtobuf). Samples are added under their text names (foo_bucket with
+// an "le" label, foo_sum, foo_created, ...) and are folded into the declared family exactly
+// like parsed lines, so decoders produce the same Result as ParseText.
+type Builder struct {
+	res *Result
+}
+
+// NewBuilder returns an empty Builder.
+func NewBuilder() *Builder {
+	return &Builder{res: newResult()}
+}
+
+// Declare records the "# TYPE", "# HELP" and "# UNIT" metadata of a family.
+// Declare a family before adding its samples.
+func (b *Builder) Declare(name string, t MetricType, help, unit string) {
+	f := b.res.family(name)
+	f.Type = t
+	f.Help = help
+	f.Unit = unit
+}
+
+// Add adds one sample; ts (zero when absent) and ex (nil when absent) are optional.
+// It reports false when the sample does not fit the family shape (e.g. a bucket without "le").
+func (b *Builder) Add(name string, labels map[string]string, v float64, ts time.Time, ex *Exemplar) bool {
+	return b.res.add(name, labels, v, ts, ex)
+}
+
+// Result sorts buckets and quantiles and returns the Result. The Builder must not be used afterwards.
+func (b *Builder) Result() *Result {
+	b.res.finish()
+	return b.res
+}
+
+// FormatFloat formats an "le" or "quantile" label value the way Prometheus client
+// libraries expose it in the text format ("0.5", "+Inf").
+func FormatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
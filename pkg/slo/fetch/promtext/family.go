@@ -0,0 +1,315 @@
+package promtext
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yeongki/my-operator/pkg/slo/common/promkey"
+)
+
+// MetricType is the "# TYPE" of a metric family.
+type MetricType string
+
+const (
+	TypeCounter   MetricType = "counter"
+	TypeGauge     MetricType = "gauge"
+	TypeHistogram MetricType = "histogram"
+	TypeSummary   MetricType = "summary"
+	TypeUntyped   MetricType = "untyped"
+
+	// OpenMetrics only. Gauge histograms are kept as Histograms (_gsum/_gcount fill Sum/Count),
+	// info and stateset samples as Series. "unknown" is parsed as TypeUntyped.
+	TypeGaugeHistogram MetricType = "gaugehistogram"
+	TypeInfo           MetricType = "info"
+	TypeStateset       MetricType = "stateset"
+)
+
+// Exemplar is an OpenMetrics exemplar: one observation behind a sample, usually with a trace id.
+type Exemplar struct {
+	Labels    map[string]string
+	Value     float64
+	Timestamp time.Time // zero when absent
+}
+
+// Series is one plain sample line (counter/gauge/untyped).
+type Series struct {
+	Labels map[string]string
+	Value  float64
+
+	// OpenMetrics only: creation time of a counter (from <name>_created) and its exemplar.
+	Created  time.Time
+	Exemplar *Exemplar
+}
+
+// Bucket is one cumulative histogram bucket.
+type Bucket struct {
+	UpperBound float64 // parsed "le", +Inf for the last bucket
+	Count      float64 // cumulative count
+	Exemplar   *Exemplar
+}
+
+// Histogram is one histogram series reassembled from its _bucket/_sum/_count lines.
+// Labels never contain "le".
+type Histogram struct {
+	Labels  map[string]string
+	Buckets []Bucket // sorted by UpperBound
+	Sum     float64
+	Count   float64
+	Created time.Time // OpenMetrics _created, zero when absent
+}
+
+// Quantile is one summary quantile line.
+type Quantile struct {
+	Quantile float64
+	Value    float64
+}
+
+// Summary is one summary series reassembled from its quantile/_sum/_count lines.
+// Labels never contain "quantile".
+type Summary struct {
+	Labels    map[string]string
+	Quantiles []Quantile // sorted by Quantile
+	Sum       float64
+	Count     float64
+	Created   time.Time // OpenMetrics _created, zero when absent
+}
+
+// Family is one metric family grouped by its "# TYPE" metadata.
+// Only one of Series/Histograms/Summaries is populated, depending on Type.
+type Family struct {
+	Name string
+	Type MetricType
+	Help string
+	Unit string
+
+	Series     []Series
+	Histograms []*Histogram
+	Summaries  []*Summary
+
+	// index of Histograms/Summaries by canonical label key (without le/quantile)
+	byKey map[string]int
+	// OpenMetrics _created values by canonical label key, attached by finish
+	created map[string]float64
+}
+
+// Result is the typed parse result.
+// Values keeps the flat view (same keys as ParseTextToMap) for existing callers.
+// Timestamps and Exemplars use the same keys and only hold series that expose one.
+type Result struct {
+	Families   map[string]*Family
+	Values     map[string]float64
+	Timestamps map[string]time.Time
+	Exemplars  map[string]Exemplar
+}
+
+// Family returns the family with the given name.
+func (r *Result) Family(name string) (*Family, bool) {
+	f, ok := r.Families[name]
+	return f, ok
+}
+
+// Histogram returns the histogram series whose labels equal labels exactly.
+func (f *Family) Histogram(labels map[string]string) (*Histogram, bool) {
+	i, ok := f.byKey[promkey.Format(f.Name, labels)]
+	if !ok || f.Type != TypeHistogram {
+		return nil, false
+	}
+	return f.Histograms[i], true
+}
+
+// Summary returns the summary series whose labels equal labels exactly.
+func (f *Family) Summary(labels map[string]string) (*Summary, bool) {
+	i, ok := f.byKey[promkey.Format(f.Name, labels)]
+	if !ok || f.Type != TypeSummary {
+		return nil, false
+	}
+	return f.Summaries[i], true
+}
+
+func newResult() *Result {
+	return &Result{
+		Families:   map[string]*Family{},
+		Values:     map[string]float64{},
+		Timestamps: map[string]time.Time{},
+		Exemplars:  map[string]Exemplar{},
+	}
+}
+
+func (r *Result) family(name string) *Family {
+	f, ok := r.Families[name]
+	if !ok {
+		f = &Family{Name: name, Type: TypeUntyped, byKey: map[string]int{}, created: map[string]float64{}}
+		r.Families[name] = f
+	}
+	return f
+}
+
+// childSuffixes lists the sample suffixes folded into a declared family of each type.
+// Classic counters are declared with their _total name and need no folding;
+// OpenMetrics declares "foo" and exposes foo_total and foo_created.
+var childSuffixes = map[MetricType][]string{
+	TypeCounter:        {"_total", "_created"},
+	TypeHistogram:      {"_bucket", "_sum", "_count", "_created"},
+	TypeGaugeHistogram: {"_bucket", "_gsum", "_gcount"},
+	TypeSummary:        {"_sum", "_count", "_created"},
+	TypeInfo:           {"_info"},
+}
+
+var allChildSuffixes = []string{"_bucket", "_sum", "_count", "_created", "_total", "_gsum", "_gcount", "_info"}
+
+// familyFor resolves which family a sample name belongs to.
+// Children such as _bucket/_sum/_count are folded into their declared parent.
+func (r *Result) familyFor(sampleName string) (*Family, string) {
+	if f, ok := r.Families[sampleName]; ok {
+		return f, ""
+	}
+	for _, suffix := range allChildSuffixes {
+		base, ok := strings.CutSuffix(sampleName, suffix)
+		if !ok {
+			continue
+		}
+		f, ok := r.Families[base]
+		if ok && slices.Contains(childSuffixes[f.Type], suffix) {
+			return f, suffix
+		}
+	}
+	return r.family(sampleName), ""
+}
+
+// add attaches one parsed sample line to its family and to the flat views.
+// _created samples are timestamps, not values: they are only kept on the typed series.
+// ts is the exposed sample timestamp (zero when absent).
+// It reports false when the line does not fit the family shape (e.g. missing "le").
+func (r *Result) add(name string, labels map[string]string, v float64, ts time.Time, ex *Exemplar) bool {
+	f, suffix := r.familyFor(name)
+	if suffix == "_created" {
+		f.created[promkey.Format(f.Name, labels)] = v
+		return true
+	}
+	key := promkey.Format(name, labels)
+	r.Values[key] = v
+	if !ts.IsZero() {
+		r.Timestamps[key] = ts
+	}
+	if ex != nil {
+		r.Exemplars[key] = *ex
+	}
+
+	switch f.Type {
+	case TypeHistogram, TypeGaugeHistogram:
+		h := f.histogram(labels)
+		switch suffix {
+		case "_bucket":
+			le, ok := labels["le"]
+			if !ok {
+				return false
+			}
+			ub, err := parseFloat(le)
+			if err != nil {
+				return false
+			}
+			h.Buckets = append(h.Buckets, Bucket{UpperBound: ub, Count: v, Exemplar: ex})
+		case "_sum", "_gsum":
+			h.Sum = v
+		case "_count", "_gcount":
+			h.Count = v
+		default:
+			return false
+		}
+	case TypeSummary:
+		s := f.summary(labels)
+		switch suffix {
+		case "":
+			q, ok := labels["quantile"]
+			if !ok {
+				return false
+			}
+			qv, err := parseFloat(q)
+			if err != nil {
+				return false
+			}
+			s.Quantiles = append(s.Quantiles, Quantile{Quantile: qv, Value: v})
+		case "_sum":
+			s.Sum = v
+		case "_count":
+			s.Count = v
+		default:
+			return false
+		}
+	default:
+		f.Series = append(f.Series, Series{Labels: labels, Value: v, Exemplar: ex})
+	}
+	return true
+}
+
+func (f *Family) histogram(labels map[string]string) *Histogram {
+	base := withoutLabel(labels, "le")
+	key := promkey.Format(f.Name, base)
+	if i, ok := f.byKey[key]; ok {
+		return f.Histograms[i]
+	}
+	h := &Histogram{Labels: base}
+	f.byKey[key] = len(f.Histograms)
+	f.Histograms = append(f.Histograms, h)
+	return h
+}
+
+func (f *Family) summary(labels map[string]string) *Summary {
+	base := withoutLabel(labels, "quantile")
+	key := promkey.Format(f.Name, base)
+	if i, ok := f.byKey[key]; ok {
+		return f.Summaries[i]
+	}
+	s := &Summary{Labels: base}
+	f.byKey[key] = len(f.Summaries)
+	f.Summaries = append(f.Summaries, s)
+	return s
+}
+
+// finish sorts buckets/quantiles and attaches _created values once all lines are read.
+func (r *Result) finish() {
+	for _, f := range r.Families {
+		for _, h := range f.Histograms {
+			sort.SliceStable(h.Buckets, func(i, j int) bool {
+				return h.Buckets[i].UpperBound < h.Buckets[j].UpperBound
+			})
+			h.Created = f.createdAt(h.Labels)
+		}
+		for _, s := range f.Summaries {
+			sort.SliceStable(s.Quantiles, func(i, j int) bool {
+				return s.Quantiles[i].Quantile < s.Quantiles[j].Quantile
+			})
+			s.Created = f.createdAt(s.Labels)
+		}
+		for i := range f.Series {
+			f.Series[i].Created = f.createdAt(f.Series[i].Labels)
+		}
+	}
+}
+
+func (f *Family) createdAt(labels map[string]string) time.Time {
+	v, ok := f.created[promkey.Format(f.Name, labels)]
+	if !ok {
+		return time.Time{}
+	}
+	return unixSeconds(v)
+}
+
+// unixSeconds converts an OpenMetrics timestamp (seconds, fractional) to time.
+func unixSeconds(v float64) time.Time {
+	sec, frac := math.Modf(v)
+	return time.Unix(int64(sec), int64(frac*1e9)).UTC()
+}
+
+func withoutLabel(labels map[string]string, drop string) map[string]string {
+	out := make(map[string]string, len(labels))
+	for k, v := range labels {
+		if k != drop {
+			out[k] = v
+		}
+	}
+	return out
+}
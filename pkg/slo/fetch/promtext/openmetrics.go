@@ -0,0 +1,152 @@
+package promtext
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/yeongki/my-operator/pkg/slo/common/promkey"
+)
+
+// ContentTypeOpenMetrics is the media type of the OpenMetrics text format.
+const ContentTypeOpenMetrics = "application/openmetrics-text"
+
+const eofMarker = "# EOF"
+
+// IsOpenMetrics reports whether body is an OpenMetrics exposition.
+// OpenMetrics bodies must end with "# EOF"; the classic text format has no such marker.
+// A truncated OpenMetrics body lacks it too and is indistinguishable from the classic format:
+// when the Content-Type is known, select the parser from it instead (fetch.ParseSampleType).
+func IsOpenMetrics(body []byte) bool {
+	return bytes.HasSuffix(bytes.TrimRight(body, "\n"), []byte(eofMarker))
+}
+
+// ParseOpenMetrics parses the OpenMetrics text format into the same typed Result as ParseText.
+//
+// On top of the classic format it handles:
+//   - counter families declared without _total ("# TYPE foo counter", sample foo_total)
+//   - _created samples: kept as Created on the series, not as values
+//   - exemplars after " # ": kept on the series or histogram bucket
+//   - sample timestamps in (fractional) seconds: kept in Result.Timestamps
+//   - gaugehistogram, info and stateset families
+//   - "# EOF": required, a body without it is truncated and rejected
+//
+// +Inf, -Inf and NaN values are accepted (the classic parser accepts them as well).
+// Malformed metric keys are skipped like in ParseText; malformed values, timestamps and
+// exemplars are errors.
+func ParseOpenMetrics(r io.Reader) (*Result, error) {
+	res := newResult()
+	sc := bufio.NewScanner(r)
+
+	eof := false
+	for lineNo := 1; sc.Scan(); lineNo++ {
+		line := sc.Text()
+		if eof {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: content after %s", lineNo, eofMarker)
+		}
+		switch {
+		case line == eofMarker:
+			eof = true
+			continue
+		case strings.TrimSpace(line) == "":
+			continue
+		case strings.HasPrefix(line, "#"):
+			parseMeta(res, line)
+			continue
+		}
+
+		rawKey, rest := splitSample(line)
+		name, labels, err := promkey.Parse(rawKey)
+		if err != nil {
+			continue
+		}
+		v, ts, ex, err := parseOpenMetricsRest(rest)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %q: %w", lineNo, line, err)
+		}
+		res.add(name, labels, v, ts, ex)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if !eof {
+		return nil, errors.New("missing " + eofMarker + " (truncated body?)")
+	}
+
+	res.finish()
+	return res, nil
+}
+
+// ParseOpenMetricsToMap is ParseOpenMetrics reduced to the flat view of ParseTextToMap.
+func ParseOpenMetricsToMap(r io.Reader) (map[string]float64, error) {
+	res, err := ParseOpenMetrics(r)
+	if err != nil {
+		return nil, err
+	}
+	return res.Values, nil
+}
+
+// parseOpenMetricsRest parses "value [timestamp] [# {labels} value [timestamp]]".
+func parseOpenMetricsRest(rest string) (v float64, ts time.Time, ex *Exemplar, err error) {
+	sample, exemplar, hasExemplar := strings.Cut(rest, " # ")
+	fields := strings.Fields(sample)
+	if len(fields) < 1 || len(fields) > 2 {
+		return 0, time.Time{}, nil, fmt.Errorf("want value [timestamp], got %q", strings.TrimSpace(sample))
+	}
+	if v, err = parseFloat(fields[0]); err != nil {
+		return 0, time.Time{}, nil, fmt.Errorf("parse value: %w", err)
+	}
+	if len(fields) == 2 {
+		if ts, err = parseTimestamp(fields[1]); err != nil {
+			return 0, time.Time{}, nil, err
+		}
+	}
+	if hasExemplar {
+		if ex, err = parseExemplar(strings.TrimSpace(exemplar)); err != nil {
+			return 0, time.Time{}, nil, err
+		}
+	}
+	return v, ts, ex, nil
+}
+
+// parseExemplar parses `{trace_id="abc"} 0.05 [1520879607.789]`.
+func parseExemplar(s string) (*Exemplar, error) {
+	if !strings.HasPrefix(s, "{") {
+		return nil, fmt.Errorf("exemplar: want {labels}, got %q", s)
+	}
+	rawLabels, rest := splitSample(s)
+	_, labels, err := promkey.Parse(rawLabels)
+	if err != nil {
+		return nil, fmt.Errorf("exemplar: %w", err)
+	}
+	fields := strings.Fields(rest)
+	if len(fields) < 1 || len(fields) > 2 {
+		return nil, fmt.Errorf("exemplar: want value [timestamp], got %q", strings.TrimSpace(rest))
+	}
+	ex := &Exemplar{Labels: labels}
+	if ex.Value, err = parseFloat(fields[0]); err != nil {
+		return nil, fmt.Errorf("exemplar value: %w", err)
+	}
+	if len(fields) == 2 {
+		if ex.Timestamp, err = parseTimestamp(fields[1]); err != nil {
+			return nil, fmt.Errorf("exemplar %w", err)
+		}
+	}
+	return ex, nil
+}
+
+// parseTimestamp parses an OpenMetrics timestamp: unix seconds, optionally fractional.
+func parseTimestamp(s string) (time.Time, error) {
+	v, err := parseFloat(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("timestamp: %w", err)
+	}
+	return unixSeconds(v), nil
+}
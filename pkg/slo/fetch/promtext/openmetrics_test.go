@@ -0,0 +1,111 @@
+package promtext
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+const omFixture = `# TYPE controller_runtime_reconcile counter
+# HELP controller_runtime_reconcile Total number of reconciliations per controller
+controller_runtime_reconcile_total{controller="job",result="success"} 5 1700000000.5 # {trace_id="abc"} 1 1700000000.25
+controller_runtime_reconcile_created{controller="job",result="success"} 1699990000
+# TYPE controller_runtime_reconcile_time_seconds histogram
+# UNIT controller_runtime_reconcile_time_seconds seconds
+controller_runtime_reconcile_time_seconds_bucket{controller="job",le="0.1"} 3
+controller_runtime_reconcile_time_seconds_bucket{controller="job",le="+Inf"} 4 # {trace_id="slow"} 7.5
+controller_runtime_reconcile_time_seconds_sum{controller="job"} NaN
+controller_runtime_reconcile_time_seconds_count{controller="job"} 4
+controller_runtime_reconcile_time_seconds_created{controller="job"} 1699990000
+# TYPE build info
+build_info{version="v1.2.3"} 1
+# TYPE workqueue_depth gauge
+workqueue_depth{name="job"} -Inf
+# EOF
+`
+
+func TestParseOpenMetrics(t *testing.T) {
+	res, err := ParseOpenMetrics(strings.NewReader(omFixture))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	cf, ok := res.Family("controller_runtime_reconcile")
+	if !ok || cf.Type != TypeCounter || len(cf.Series) != 1 {
+		t.Fatalf("unexpected counter family: %+v", cf)
+	}
+	s := cf.Series[0]
+	if s.Value != 5 || !s.Created.Equal(time.Unix(1699990000, 0)) {
+		t.Fatalf("unexpected counter series: %+v", s)
+	}
+	if s.Exemplar == nil || s.Exemplar.Labels["trace_id"] != "abc" || s.Exemplar.Value != 1 ||
+		!s.Exemplar.Timestamp.Equal(time.Unix(1700000000, 250_000_000)) {
+		t.Fatalf("unexpected exemplar: %+v", s.Exemplar)
+	}
+
+	hf, _ := res.Family("controller_runtime_reconcile_time_seconds")
+	h, ok := hf.Histogram(map[string]string{"controller": "job"})
+	if !ok || hf.Unit != "seconds" || len(h.Buckets) != 2 || !math.IsNaN(h.Sum) || h.Created.IsZero() {
+		t.Fatalf("unexpected histogram: %+v (unit %q)", h, hf.Unit)
+	}
+	if ex := h.Buckets[1].Exemplar; ex == nil || ex.Labels["trace_id"] != "slow" || !ex.Timestamp.IsZero() {
+		t.Fatalf("unexpected bucket exemplar: %+v", ex)
+	}
+
+	if f, ok := res.Family("build"); !ok || f.Type != TypeInfo || len(f.Series) != 1 {
+		t.Fatalf("unexpected info family: %+v", f)
+	}
+
+	// flat keys match a classic scrape; _created is not a value
+	for key, want := range map[string]float64{
+		`controller_runtime_reconcile_total{controller="job",result="success"}`:        5,
+		`controller_runtime_reconcile_time_seconds_bucket{controller="job",le="+Inf"}`: 4,
+		`build_info{version="v1.2.3"}`:                                                 1,
+		`workqueue_depth{name="job"}`:                                                  math.Inf(-1),
+	} {
+		if got, ok := res.Values[key]; !ok || got != want {
+			t.Fatalf("expected %s=%v, got %v (present=%v)", key, want, got, ok)
+		}
+	}
+	const counterKey = `controller_runtime_reconcile_total{controller="job",result="success"}`
+	if ts := res.Timestamps[counterKey]; !ts.Equal(time.Unix(1700000000, 500_000_000)) {
+		t.Fatalf("expected sample timestamp, got %v", ts)
+	}
+	if ex, ok := res.Exemplars[counterKey]; !ok || ex.Labels["trace_id"] != "abc" {
+		t.Fatalf("expected flat exemplar, got %+v", res.Exemplars)
+	}
+	for key := range res.Values {
+		if strings.Contains(key, "_created") {
+			t.Fatalf("expected _created to be dropped from values, got %s", key)
+		}
+	}
+}
+
+func TestParseOpenMetricsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "truncated", body: "up 1\n", want: "missing # EOF"},
+		{name: "after eof", body: "up 1\n# EOF\nup 2\n", want: "content after # EOF"},
+		{name: "bad value", body: "up one\n# EOF\n", want: "parse value"},
+		{name: "bad timestamp", body: "up 1 now\n# EOF\n", want: "timestamp"},
+		{name: "bad exemplar", body: "up_total 1 # trace 1\n# EOF\n", want: "exemplar"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseOpenMetrics(strings.NewReader(tt.body))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestIsOpenMetrics(t *testing.T) {
+	if !IsOpenMetrics([]byte(omFixture)) || IsOpenMetrics([]byte(fixture)) {
+		t.Fatal("expected only the OpenMetrics fixture to be detected")
+	}
+}
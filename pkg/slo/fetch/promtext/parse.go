@@ -6,6 +6,7 @@ import (
 	"io"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/yeongki/my-operator/pkg/slo/common/promkey"
 )
@@ -20,38 +21,134 @@ import (
 //	metric_name
 //
 // v3: minimal parser for common cases (counters/gauges).
+// Histogram/summary children are kept as plain keys (name_bucket{le="..."}, name_sum, name_count).
 func ParseTextToMap(r io.Reader) (map[string]float64, error) {
-	out := map[string]float64{}
+	res, err := ParseText(r)
+	if err != nil {
+		return nil, err
+	}
+	return res.Values, nil
+}
+
+// ParseText parses Prometheus exposition format (text) into families grouped by "# TYPE".
+// Histogram buckets are reassembled (sorted by le) and summary quantiles are grouped.
+// "# HELP" and "# UNIT" metadata are kept on the family. The optional timestamp column
+// (milliseconds) is kept in Result.Timestamps.
+//
+// Malformed metric keys and timestamps are skipped (best-effort, same as ParseTextToMap).
+// A non-float value is an error.
+func ParseText(r io.Reader) (*Result, error) {
+	res := newResult()
 	sc := bufio.NewScanner(r)
 
 	for sc.Scan() {
 		line := strings.TrimSpace(sc.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			parseMeta(res, line)
 			continue
 		}
-		// split "key value"
-		fields := strings.Fields(line)
-		if len(fields) < 2 {
+
+		rawKey, rest := splitSample(line)
+		fields := strings.Fields(rest)
+		if rawKey == "" || len(fields) < 1 {
 			continue
 		}
-		rawKey := fields[0]
-		key, err := promkey.Canonicalize(rawKey)
+		name, labels, err := promkey.Parse(rawKey)
 		if err != nil {
 			// v3 policy: skip malformed metric lines (best-effort parser)
 			continue
 		}
-		valStr := fields[1]
-		v, err := strconv.ParseFloat(valStr, 64)
+		v, err := parseFloat(fields[0])
 		if err != nil {
 			return nil, fmt.Errorf("parse float: %q: %w", line, err)
 		}
 
-		out[key] = v
+		var ts time.Time
+		if len(fields) > 1 {
+			if ms, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+				ts = time.UnixMilli(ms).UTC()
+			}
+		}
+		res.add(name, labels, v, ts, nil)
 	}
 
 	if err := sc.Err(); err != nil {
 		return nil, err
 	}
 
-	return out, nil
+	res.finish()
+	return res, nil
+}
+
+// parseMeta handles "# HELP", "# TYPE" and "# UNIT" lines. Other comments are ignored.
+func parseMeta(res *Result, line string) {
+	fields := strings.SplitN(strings.TrimSpace(strings.TrimPrefix(line, "#")), " ", 3)
+	if len(fields) < 2 {
+		return
+	}
+	kind, name := fields[0], fields[1]
+	arg := ""
+	if len(fields) == 3 {
+		arg = strings.TrimSpace(fields[2])
+	}
+
+	switch kind {
+	case "HELP":
+		res.family(name).Help = unescapeHelp(arg)
+	case "TYPE":
+		f := res.family(name)
+		switch t := MetricType(strings.ToLower(arg)); t {
+		case TypeCounter, TypeGauge, TypeHistogram, TypeSummary, TypeGaugeHistogram, TypeInfo, TypeStateset:
+			f.Type = t
+		default:
+			f.Type = TypeUntyped
+		}
+	case "UNIT":
+		res.family(name).Unit = arg
+	}
+}
+
+// splitSample splits a sample line into the metric key and the remainder (value [timestamp]).
+// Label values may contain spaces, so the key ends at the closing '}' outside quotes.
+func splitSample(line string) (key, rest string) {
+	br := strings.IndexByte(line, '{')
+	sp := strings.IndexAny(line, " \t")
+	if br < 0 || (sp >= 0 && sp < br) {
+		if sp < 0 {
+			return line, ""
+		}
+		return line[:sp], line[sp+1:]
+	}
+
+	inQuote := false
+	for i := br + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			if inQuote {
+				i++
+			}
+		case '"':
+			inQuote = !inQuote
+		case '}':
+			if !inQuote {
+				return line[:i+1], line[i+1:]
+			}
+		}
+	}
+	// unterminated label set: let promkey.Parse reject it
+	return line, ""
+}
+
+func unescapeHelp(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	return strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\"`, `"`).Replace(s)
+}
+
+func parseFloat(s string) (float64, error) {
+	return strconv.ParseFloat(s, 64)
 }
@@ -0,0 +1,106 @@
+package promtext
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+const fixture = `# HELP joboperator_reconcile_duration_seconds JobOperator reconcile latency in seconds
+# TYPE joboperator_reconcile_duration_seconds histogram
+joboperator_reconcile_duration_seconds_bucket{name="a",namespace="default",result="success",le="1"} 3
+joboperator_reconcile_duration_seconds_bucket{name="a",namespace="default",result="success",le="+Inf"} 4
+joboperator_reconcile_duration_seconds_bucket{name="a",namespace="default",result="success",le="0.1"} 1
+joboperator_reconcile_duration_seconds_sum{name="a",namespace="default",result="success"} 2.5
+joboperator_reconcile_duration_seconds_count{name="a",namespace="default",result="success"} 4
+# HELP go_gc_duration_seconds A summary of the wall-time pause.
+# TYPE go_gc_duration_seconds summary
+go_gc_duration_seconds{quantile="1"} 0.002
+go_gc_duration_seconds{quantile="0.5"} 0.001
+go_gc_duration_seconds_sum 0.01
+go_gc_duration_seconds_count 7
+# TYPE controller_runtime_reconcile_total counter
+controller_runtime_reconcile_total{controller="joboperator",result="success"} 5
+untyped_metric{path="/a b"} 1
+`
+
+func TestParseTextGroupsFamilies(t *testing.T) {
+	res, err := ParseText(strings.NewReader(fixture))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	hf, ok := res.Family("joboperator_reconcile_duration_seconds")
+	if !ok || hf.Type != TypeHistogram {
+		t.Fatalf("expected histogram family, got %+v", hf)
+	}
+	if hf.Help != "JobOperator reconcile latency in seconds" {
+		t.Fatalf("expected help to be kept, got %q", hf.Help)
+	}
+	h, ok := hf.Histogram(map[string]string{"name": "a", "namespace": "default", "result": "success"})
+	if !ok {
+		t.Fatalf("expected histogram series")
+	}
+	if len(h.Buckets) != 3 {
+		t.Fatalf("expected 3 buckets, got %d", len(h.Buckets))
+	}
+	if h.Buckets[0].UpperBound != 0.1 || h.Buckets[1].UpperBound != 1 || !math.IsInf(h.Buckets[2].UpperBound, 1) {
+		t.Fatalf("expected buckets sorted by le, got %+v", h.Buckets)
+	}
+	if h.Sum != 2.5 || h.Count != 4 {
+		t.Fatalf("expected sum=2.5 count=4, got sum=%v count=%v", h.Sum, h.Count)
+	}
+
+	sf, ok := res.Family("go_gc_duration_seconds")
+	if !ok || sf.Type != TypeSummary {
+		t.Fatalf("expected summary family, got %+v", sf)
+	}
+	s, ok := sf.Summary(map[string]string{})
+	if !ok || len(s.Quantiles) != 2 || s.Quantiles[0].Quantile != 0.5 || s.Count != 7 {
+		t.Fatalf("unexpected summary: %+v", s)
+	}
+
+	cf, ok := res.Family("controller_runtime_reconcile_total")
+	if !ok || cf.Type != TypeCounter || len(cf.Series) != 1 {
+		t.Fatalf("unexpected counter family: %+v", cf)
+	}
+
+	uf, ok := res.Family("untyped_metric")
+	if !ok || uf.Type != TypeUntyped || uf.Series[0].Labels["path"] != "/a b" {
+		t.Fatalf("unexpected untyped family: %+v", uf)
+	}
+}
+
+func TestParseTextToMapKeepsFlatKeys(t *testing.T) {
+	m, err := ParseTextToMap(strings.NewReader(fixture))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for key, want := range map[string]float64{
+		`joboperator_reconcile_duration_seconds_bucket{le="+Inf",name="a",namespace="default",result="success"}`: 4,
+		`joboperator_reconcile_duration_seconds_count{name="a",namespace="default",result="success"}`:            4,
+		`go_gc_duration_seconds{quantile="0.5"}`:                                                                 0.001,
+		`controller_runtime_reconcile_total{controller="joboperator",result="success"}`:                          5,
+	} {
+		if got, ok := m[key]; !ok || got != want {
+			t.Fatalf("expected %s=%v, got %v (present=%v)", key, want, got, ok)
+		}
+	}
+}
+
+func TestParseTextKeepsTimestamps(t *testing.T) {
+	res, err := ParseText(strings.NewReader("up 1 1700000000123\nqueue_depth 2\nbad_ts 3 soon\n"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if ts := res.Timestamps["up"]; !ts.Equal(time.UnixMilli(1700000000123)) {
+		t.Fatalf("expected millisecond timestamp, got %v", ts)
+	}
+	if _, ok := res.Timestamps["queue_depth"]; ok {
+		t.Fatal("expected no timestamp for a series without one")
+	}
+	if _, ok := res.Timestamps["bad_ts"]; ok || res.Values["bad_ts"] != 3 {
+		t.Fatal("expected a malformed timestamp to be ignored")
+	}
+}
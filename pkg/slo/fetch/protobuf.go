@@ -0,0 +1,195 @@
+package fetch
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/yeongki/my-operator/pkg/slo/fetch/promtext"
+)
+
+// ContentTypeProtobuf is the media type of the delimited protobuf exposition format.
+const ContentTypeProtobuf = contentTypeProtobufBase + ";proto=" + protobufProto + ";encoding=delimited"
+
+const (
+	contentTypeProtobufBase = "application/vnd.google.protobuf"
+	protobufProto           = "io.prometheus.client.MetricFamily"
+)
+
+// IsProtobuf reports whether body looks like a delimited protobuf exposition: a varint
+// length followed by a MetricFamily starting with its name (field 1), a valid metric name.
+// It is a heuristic for bodies without a Content-Type (see ParseSampleType). Text bodies
+// start with '#' or a metric name; the name check keeps lines like "#\n# HELP ..." (varint
+// 35, then 0x0a) from matching.
+func IsProtobuf(body []byte) bool {
+	n, k := binary.Uvarint(body)
+	if k <= 0 || n == 0 || uint64(len(body)-k) < n || body[k] != 0x0a {
+		return false
+	}
+	msg := body[k : k+int(n)]
+	l, j := binary.Uvarint(msg[1:])
+	if j <= 0 || l == 0 || uint64(len(msg)-1-j) < l {
+		return false
+	}
+	return validMetricName(msg[1+j : 1+j+int(l)])
+}
+
+// validMetricName reports whether name matches [a-zA-Z_:][a-zA-Z0-9_:]*.
+func validMetricName(name []byte) bool {
+	for i, c := range name {
+		switch {
+		case c == '_' || c == ':' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		case i > 0 && c >= '0' && c <= '9':
+		default:
+			return false
+		}
+	}
+	return len(name) > 0
+}
+
+// ParseProtobuf decodes the delimited protobuf exposition format into the same typed Result
+// as promtext.ParseText: samples get their text names and keys (foo_bucket{le="0.5"},
+// foo_sum, ...), and a histogram without an explicit +Inf bucket gets one from its count,
+// as the text format exposes it. Native histogram buckets are ignored.
+// timestamp_ms is kept in Result.Timestamps, counter and bucket exemplars in Result.Exemplars.
+func ParseProtobuf(r io.Reader) (*promtext.Result, error) {
+	b := promtext.NewBuilder()
+	br := bufio.NewReader(r)
+	for {
+		mf := &dto.MetricFamily{}
+		err := protodelim.UnmarshalFrom(br, mf)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode metric family: %w", err)
+		}
+		addFamily(b, mf)
+	}
+	return b.Result(), nil
+}
+
+func addFamily(b *promtext.Builder, mf *dto.MetricFamily) {
+	name := mf.GetName()
+	b.Declare(name, metricType(mf.GetType()), mf.GetHelp(), mf.GetUnit())
+
+	for _, m := range mf.GetMetric() {
+		labels := make(map[string]string, len(m.GetLabel()))
+		for _, lp := range m.GetLabel() {
+			labels[lp.GetName()] = lp.GetValue()
+		}
+		var ts time.Time
+		if m.TimestampMs != nil {
+			ts = time.UnixMilli(m.GetTimestampMs()).UTC()
+		}
+
+		switch mf.GetType() {
+		case dto.MetricType_COUNTER:
+			c := m.GetCounter()
+			b.Add(name, labels, c.GetValue(), ts, exemplar(c.GetExemplar()))
+			addCreated(b, name, labels, c.GetCreatedTimestamp())
+		case dto.MetricType_GAUGE:
+			b.Add(name, labels, m.GetGauge().GetValue(), ts, nil)
+		case dto.MetricType_SUMMARY:
+			s := m.GetSummary()
+			for _, q := range s.GetQuantile() {
+				b.Add(name, withLabel(labels, "quantile", q.GetQuantile()), q.GetValue(), ts, nil)
+			}
+			b.Add(name+"_sum", labels, s.GetSampleSum(), ts, nil)
+			b.Add(name+"_count", labels, float64(s.GetSampleCount()), ts, nil)
+			addCreated(b, name, labels, s.GetCreatedTimestamp())
+		case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+			addHistogram(b, name, mf.GetType(), labels, m.GetHistogram(), ts)
+		default:
+			b.Add(name, labels, m.GetUntyped().GetValue(), ts, nil)
+		}
+	}
+}
+
+func addHistogram(b *promtext.Builder, name string, t dto.MetricType, labels map[string]string,
+	h *dto.Histogram, ts time.Time) {
+	count := float64(h.GetSampleCount())
+	if h.SampleCountFloat != nil {
+		count = h.GetSampleCountFloat()
+	}
+	sumSuffix, countSuffix := "_sum", "_count"
+	if t == dto.MetricType_GAUGE_HISTOGRAM {
+		sumSuffix, countSuffix = "_gsum", "_gcount"
+	}
+
+	infSeen := false
+	for _, bk := range h.GetBucket() {
+		cum := float64(bk.GetCumulativeCount())
+		if bk.CumulativeCountFloat != nil {
+			cum = bk.GetCumulativeCountFloat()
+		}
+		infSeen = infSeen || math.IsInf(bk.GetUpperBound(), 1)
+		b.Add(name+"_bucket", withLabel(labels, "le", bk.GetUpperBound()), cum, ts, exemplar(bk.GetExemplar()))
+	}
+	if !infSeen {
+		b.Add(name+"_bucket", withLabel(labels, "le", math.Inf(1)), count, ts, nil)
+	}
+	b.Add(name+sumSuffix, labels, h.GetSampleSum(), ts, nil)
+	b.Add(name+countSuffix, labels, count, ts, nil)
+	if t == dto.MetricType_HISTOGRAM {
+		addCreated(b, name, labels, h.GetCreatedTimestamp())
+	}
+}
+
+// addCreated adds a created timestamp as the OpenMetrics <name>_created sample.
+// Classic counter families are named with _total, which familyFor folds the same way.
+func addCreated(b *promtext.Builder, name string, labels map[string]string, created *timestamppb.Timestamp) {
+	if !created.IsValid() {
+		return
+	}
+	t := created.AsTime()
+	b.Add(name+"_created", labels, float64(t.UnixNano())/1e9, time.Time{}, nil)
+}
+
+func metricType(t dto.MetricType) promtext.MetricType {
+	switch t {
+	case dto.MetricType_COUNTER:
+		return promtext.TypeCounter
+	case dto.MetricType_GAUGE:
+		return promtext.TypeGauge
+	case dto.MetricType_SUMMARY:
+		return promtext.TypeSummary
+	case dto.MetricType_HISTOGRAM:
+		return promtext.TypeHistogram
+	case dto.MetricType_GAUGE_HISTOGRAM:
+		return promtext.TypeGaugeHistogram
+	default:
+		return promtext.TypeUntyped
+	}
+}
+
+func exemplar(ex *dto.Exemplar) *promtext.Exemplar {
+	if ex == nil {
+		return nil
+	}
+	out := &promtext.Exemplar{Labels: map[string]string{}, Value: ex.GetValue()}
+	for _, lp := range ex.GetLabel() {
+		out.Labels[lp.GetName()] = lp.GetValue()
+	}
+	if ts := ex.GetTimestamp(); ts.IsValid() {
+		out.Timestamp = ts.AsTime().UTC()
+	}
+	return out
+}
+
+func withLabel(labels map[string]string, name string, v float64) map[string]string {
+	out := make(map[string]string, len(labels)+1)
+	for k, val := range labels {
+		out[k] = val
+	}
+	out[name] = promtext.FormatFloat(v)
+	return out
+}
@@ -0,0 +1,276 @@
+package fetch
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/yeongki/my-operator/pkg/slo/fetch/promtext"
+)
+
+var fixtureBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// exposition builds the same metrics for n controllers twice: as delimited protobuf and as
+// classic text, the way client_golang's promhttp would serve them.
+func exposition(t testing.TB, n int) (pb, text []byte) {
+	t.Helper()
+	reconciles := &dto.MetricFamily{
+		Name: proto.String("controller_runtime_reconcile_total"),
+		Help: proto.String("Total number of reconciliations per controller"),
+		Type: dto.MetricType_COUNTER.Enum(),
+	}
+	durations := &dto.MetricFamily{
+		Name: proto.String("controller_runtime_reconcile_time_seconds"),
+		Help: proto.String("Length of time per reconciliation per controller"),
+		Type: dto.MetricType_HISTOGRAM.Enum(),
+	}
+	depth := &dto.MetricFamily{
+		Name: proto.String("workqueue_depth"),
+		Help: proto.String("Current depth of workqueue"),
+		Type: dto.MetricType_GAUGE.Enum(),
+	}
+	latency := &dto.MetricFamily{
+		Name: proto.String("rest_client_latency_seconds"),
+		Help: proto.String("Request latency"),
+		Type: dto.MetricType_SUMMARY.Enum(),
+	}
+
+	var txt strings.Builder
+	lines := map[*dto.MetricFamily][]string{}
+	for i := range n {
+		c := fmt.Sprintf("c%04d", i)
+		for j, result := range []string{"success", "error"} {
+			v := float64(i*10 + j)
+			reconciles.Metric = append(reconciles.Metric, &dto.Metric{
+				Label:   labelPairs("controller", c, "result", result),
+				Counter: &dto.Counter{Value: proto.Float64(v)},
+			})
+			lines[reconciles] = append(lines[reconciles], fmt.Sprintf(
+				"controller_runtime_reconcile_total{controller=%q,result=%q} %s", c, result, fmtFloat(v)))
+		}
+
+		h := &dto.Histogram{SampleCount: proto.Uint64(uint64(100 + i)), SampleSum: proto.Float64(float64(i) + 0.5)}
+		for k, ub := range fixtureBuckets {
+			cum := uint64(k * (100 + i) / len(fixtureBuckets))
+			h.Bucket = append(h.Bucket, &dto.Bucket{UpperBound: proto.Float64(ub), CumulativeCount: proto.Uint64(cum)})
+			lines[durations] = append(lines[durations], fmt.Sprintf(
+				"controller_runtime_reconcile_time_seconds_bucket{controller=%q,le=%q} %d",
+				c, promtext.FormatFloat(ub), cum))
+		}
+		durations.Metric = append(durations.Metric, &dto.Metric{Label: labelPairs("controller", c), Histogram: h})
+		lines[durations] = append(lines[durations],
+			fmt.Sprintf(`controller_runtime_reconcile_time_seconds_bucket{controller=%q,le="+Inf"} %d`, c, 100+i),
+			fmt.Sprintf("controller_runtime_reconcile_time_seconds_sum{controller=%q} %s", c, fmtFloat(float64(i)+0.5)),
+			fmt.Sprintf("controller_runtime_reconcile_time_seconds_count{controller=%q} %d", c, 100+i))
+
+		depth.Metric = append(depth.Metric, &dto.Metric{
+			Label: labelPairs("name", c),
+			Gauge: &dto.Gauge{Value: proto.Float64(float64(i % 7))},
+		})
+		lines[depth] = append(lines[depth], fmt.Sprintf("workqueue_depth{name=%q} %d", c, i%7))
+
+		latency.Metric = append(latency.Metric, &dto.Metric{
+			Label: labelPairs("verb", c),
+			Summary: &dto.Summary{
+				SampleCount: proto.Uint64(10),
+				SampleSum:   proto.Float64(1.25),
+				Quantile: []*dto.Quantile{
+					{Quantile: proto.Float64(0.5), Value: proto.Float64(0.1)},
+					{Quantile: proto.Float64(0.99), Value: proto.Float64(0.4)},
+				},
+			},
+		})
+		lines[latency] = append(lines[latency],
+			fmt.Sprintf(`rest_client_latency_seconds{verb=%q,quantile="0.5"} 0.1`, c),
+			fmt.Sprintf(`rest_client_latency_seconds{verb=%q,quantile="0.99"} 0.4`, c),
+			fmt.Sprintf("rest_client_latency_seconds_sum{verb=%q} 1.25", c),
+			fmt.Sprintf("rest_client_latency_seconds_count{verb=%q} 10", c))
+	}
+
+	var buf bytes.Buffer
+	for _, mf := range []*dto.MetricFamily{reconciles, durations, depth, latency} {
+		if _, err := protodelim.MarshalTo(&buf, mf); err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		fmt.Fprintf(&txt, "# HELP %s %s\n# TYPE %s %s\n", mf.GetName(), mf.GetHelp(),
+			mf.GetName(), strings.ToLower(mf.GetType().String()))
+		txt.WriteString(strings.Join(lines[mf], "\n") + "\n")
+	}
+	return buf.Bytes(), []byte(txt.String())
+}
+
+func labelPairs(kv ...string) []*dto.LabelPair {
+	out := make([]*dto.LabelPair, 0, len(kv)/2)
+	for i := 0; i < len(kv); i += 2 {
+		out = append(out, &dto.LabelPair{Name: proto.String(kv[i]), Value: proto.String(kv[i+1])})
+	}
+	return out
+}
+
+func fmtFloat(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+
+func TestParseProtobufMatchesText(t *testing.T) {
+	pb, text := exposition(t, 3)
+	want, err := promtext.ParseText(bytes.NewReader(text))
+	if err != nil {
+		t.Fatalf("parse text: %v", err)
+	}
+	got, err := ParseProtobuf(bytes.NewReader(pb))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !reflect.DeepEqual(got.Values, want.Values) {
+		t.Fatalf("values differ:\nprotobuf %v\ntext     %v", got.Values, want.Values)
+	}
+	if len(got.Families) != len(want.Families) {
+		t.Fatalf("expected %d families, got %d", len(want.Families), len(got.Families))
+	}
+	for name, wf := range want.Families {
+		gf, ok := got.Family(name)
+		if !ok {
+			t.Fatalf("family %s missing", name)
+		}
+		if gf.Type != wf.Type || gf.Help != wf.Help ||
+			!reflect.DeepEqual(gf.Series, wf.Series) ||
+			!reflect.DeepEqual(gf.Histograms, wf.Histograms) ||
+			!reflect.DeepEqual(gf.Summaries, wf.Summaries) {
+			t.Fatalf("family %s differs:\nprotobuf %+v\ntext     %+v", name, gf, wf)
+		}
+	}
+
+	h, ok := got.Families["controller_runtime_reconcile_time_seconds"].Histogram(map[string]string{"controller": "c0001"})
+	if !ok || len(h.Buckets) != len(fixtureBuckets)+1 || !math.IsInf(h.Buckets[len(h.Buckets)-1].UpperBound, 1) {
+		t.Fatalf("expected histogram with synthesized +Inf bucket, got %+v", h)
+	}
+}
+
+func TestParseProtobufKeepsTimestampsAndExemplars(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	mf := &dto.MetricFamily{
+		Name: proto.String("controller_runtime_reconcile_total"),
+		Type: dto.MetricType_COUNTER.Enum(),
+		Metric: []*dto.Metric{{
+			Label: labelPairs("controller", "joboperator"),
+			Counter: &dto.Counter{
+				Value:            proto.Float64(7),
+				CreatedTimestamp: timestamppb.New(at.Add(-time.Hour)),
+				Exemplar: &dto.Exemplar{
+					Label:     labelPairs("trace_id", "abc"),
+					Value:     proto.Float64(1),
+					Timestamp: timestamppb.New(at),
+				},
+			},
+			TimestampMs: proto.Int64(at.UnixMilli()),
+		}},
+	}
+	var buf bytes.Buffer
+	if _, err := protodelim.MarshalTo(&buf, mf); err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	sample, err := ParseSample(buf.Bytes(), at)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	key := `controller_runtime_reconcile_total{controller="joboperator"}`
+	if sample.Values[key] != 7 || len(sample.Values) != 1 {
+		t.Fatalf("expected only %s=7 (no _created value), got %v", key, sample.Values)
+	}
+	if !sample.Timestamps[key].Equal(at) {
+		t.Fatalf("expected timestamp %v, got %v", at, sample.Timestamps[key])
+	}
+	ex := sample.Exemplars[key]
+	if ex.Labels["trace_id"] != "abc" || !ex.Timestamp.Equal(at) {
+		t.Fatalf("unexpected exemplar %+v", ex)
+	}
+
+	res, _ := ParseProtobuf(bytes.NewReader(buf.Bytes()))
+	series := res.Families["controller_runtime_reconcile_total"].Series
+	if len(series) != 1 || !series[0].Created.Equal(at.Add(-time.Hour)) {
+		t.Fatalf("expected created timestamp on series, got %+v", series)
+	}
+}
+
+func TestIsProtobuf(t *testing.T) {
+	pb, text := exposition(t, 1)
+	if !IsProtobuf(pb) {
+		t.Fatal("expected protobuf body to be detected")
+	}
+	// "#\n" reads as varint 35 followed by 0x0a: only the name check rejects it
+	emptyComment := "#\n# HELP workqueue_depth Current depth of workqueue.\nworkqueue_depth 3\n"
+	for _, body := range []string{string(text), "workqueue_depth 3\n", "# EOF\n", "", emptyComment} {
+		if IsProtobuf([]byte(body)) {
+			t.Fatalf("expected %q not to be detected as protobuf", body)
+		}
+	}
+	if _, err := ParseProtobuf(bytes.NewReader(pb[:len(pb)-3])); err == nil {
+		t.Fatal("expected truncated body to fail")
+	}
+}
+
+func TestParseSampleTypeFollowsContentType(t *testing.T) {
+	pb, text := exposition(t, 1)
+	const key = `controller_runtime_reconcile_total{controller="c0000",result="success"}`
+	at := time.Unix(1700000000, 0)
+
+	for _, tt := range []struct {
+		name, contentType string
+		body              []byte
+	}{
+		{"protobuf", ContentTypeProtobuf, pb},
+		{"protobuf, parameters reordered", "application/vnd.google.protobuf; encoding=delimited; " +
+			"proto=io.prometheus.client.MetricFamily", pb},
+		{"text", "text/plain; version=0.0.4; charset=utf-8", append([]byte("#\n"), text...)},
+		{"no content type", "", pb},
+		{"unknown content type", "application/octet-stream", text},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseSampleType(tt.body, tt.contentType, at)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if _, ok := s.Values[key]; !ok {
+				t.Fatalf("expected %s in %d values", key, len(s.Values))
+			}
+		})
+	}
+
+	if _, err := ParseSampleType(text, ContentTypeProtobuf, at); err == nil {
+		t.Fatal("expected a text body served as protobuf to fail instead of being guessed")
+	}
+}
+
+// BenchmarkParseProtobuf and BenchmarkParseTextToMap decode the same large exposition
+// (2000 controllers, ~36k series).
+func BenchmarkParseProtobuf(b *testing.B) {
+	pb, _ := exposition(b, 2000)
+	b.SetBytes(int64(len(pb)))
+	b.ResetTimer()
+	for range b.N {
+		if _, err := ParseProtobuf(bytes.NewReader(pb)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseTextToMap(b *testing.B) {
+	_, text := exposition(b, 2000)
+	b.SetBytes(int64(len(text)))
+	b.ResetTimer()
+	for range b.N {
+		if _, err := promtext.ParseTextToMap(bytes.NewReader(text)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// recordingLayout keeps file names sortable and free of ':' (Windows, artifact uploaders).
+	recordingLayout = "20060102T150405.000000000Z"
+	recordingPrefix = "scrape-"
+	recordingExt    = ".prom"
+	// contentTypeExt names the sidecar file holding the Content-Type of a recording.
+	contentTypeExt = ".content-type"
+)
+
+// RecordingFetcher scrapes raw bodies through Scraper, stores each one in Dir as
+// scrape-<UTC timestamp>.prom and returns the parsed sample. The Content-Type of the
+// response, if any, is stored next to it in scrape-<UTC timestamp>.prom.content-type.
+// A recording directory can be replayed later with ReplayFetcher.
+type RecordingFetcher struct {
+	Scraper Scraper
+	Dir     string
+
+	mu sync.Mutex
+}
+
+// NewRecordingFetcher records every scrape of s into dir (created if missing).
+func NewRecordingFetcher(s Scraper, dir string) *RecordingFetcher {
+	return &RecordingFetcher{Scraper: s, Dir: dir}
+}
+
+// Fetch scrapes, records, then parses. A failed write is returned as error:
+// a recording with holes would replay a different window than the one measured.
+func (f *RecordingFetcher) Fetch(ctx context.Context, at time.Time) (Sample, error) {
+	body, contentType, err := f.Scraper.Scrape(ctx)
+	if err != nil {
+		return Sample{}, err
+	}
+	if err := f.record(at, body, contentType); err != nil {
+		return Sample{}, err
+	}
+	return ParseSampleType(body, contentType, at)
+}
+
+// Scrape returns the raw body and records it with the current time.
+func (f *RecordingFetcher) Scrape(ctx context.Context) ([]byte, string, error) {
+	body, contentType, err := f.Scraper.Scrape(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, contentType, f.record(time.Now(), body, contentType)
+}
+
+func (f *RecordingFetcher) record(at time.Time, body []byte, contentType string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := os.MkdirAll(f.Dir, 0o755); err != nil {
+		return fmt.Errorf("record scrape: %w", err)
+	}
+	base := recordingPrefix + at.UTC().Format(recordingLayout)
+	for i := 0; ; i++ {
+		name := base + recordingExt
+		if i > 0 {
+			name = fmt.Sprintf("%s-%d%s", base, i, recordingExt)
+		}
+		file, err := os.OpenFile(filepath.Join(f.Dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("record scrape: %w", err)
+		}
+		_, werr := file.Write(body)
+		cerr := file.Close()
+		if err := errors.Join(werr, cerr); err != nil {
+			return fmt.Errorf("record scrape: %w", err)
+		}
+		if contentType == "" {
+			return nil
+		}
+		path := filepath.Join(f.Dir, name+contentTypeExt)
+		if err := os.WriteFile(path, []byte(contentType+"\n"), 0o644); err != nil {
+			return fmt.Errorf("record scrape: %w", err)
+		}
+		return nil
+	}
+}
+
+// Recording is one recorded scrape.
+type Recording struct {
+	At   time.Time
+	Path string
+}
+
+// ReplayFetcher serves recorded scrapes (see RecordingFetcher) by nearest timestamp,
+// so a run can be re-evaluated offline against changed specs.
+// Recordings are parsed by their recorded Content-Type, as the live scrape was (see
+// ParseSampleType); recordings without one are sniffed.
+type ReplayFetcher struct {
+	recordings []Recording // sorted by At
+}
+
+// NewReplayFetcher loads the recording index of dir. Files are read lazily on Fetch.
+func NewReplayFetcher(dir string) (*ReplayFetcher, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("replay: %w", err)
+	}
+	var recs []Recording
+	seqs := map[string]int{}
+	for _, e := range entries {
+		at, seq, ok := parseRecordingName(e.Name())
+		if e.IsDir() || !ok {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		recs = append(recs, Recording{At: at, Path: path})
+		seqs[path] = seq
+	}
+	if len(recs) == 0 {
+		return nil, fmt.Errorf("replay: no recordings in %s", dir)
+	}
+	sort.Slice(recs, func(i, j int) bool {
+		if !recs[i].At.Equal(recs[j].At) {
+			return recs[i].At.Before(recs[j].At)
+		}
+		return seqs[recs[i].Path] < seqs[recs[j].Path]
+	})
+	return &ReplayFetcher{recordings: recs}, nil
+}
+
+// Recordings lists the recorded scrapes in time order.
+func (f *ReplayFetcher) Recordings() []Recording {
+	return append([]Recording(nil), f.recordings...)
+}
+
+// Fetch returns the recording closest to at (ties prefer the earlier one).
+// The sample keeps the recorded time, so the engine sees the real window.
+func (f *ReplayFetcher) Fetch(_ context.Context, at time.Time) (Sample, error) {
+	rec := f.nearest(at)
+	return f.load(rec)
+}
+
+// Samples returns every recording parsed, e.g. as ExecuteRequest.Samples for window modes.
+func (f *ReplayFetcher) Samples() ([]Sample, error) {
+	out := make([]Sample, 0, len(f.recordings))
+	for _, rec := range f.recordings {
+		s, err := f.load(rec)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func (f *ReplayFetcher) nearest(at time.Time) Recording {
+	i := sort.Search(len(f.recordings), func(i int) bool { return !f.recordings[i].At.Before(at) })
+	switch {
+	case i == 0:
+		return f.recordings[0]
+	case i == len(f.recordings):
+		return f.recordings[i-1]
+	}
+	before, after := f.recordings[i-1], f.recordings[i]
+	if after.At.Sub(at) < at.Sub(before.At) {
+		return after
+	}
+	return before
+}
+
+func (f *ReplayFetcher) load(rec Recording) (Sample, error) {
+	body, err := os.ReadFile(rec.Path)
+	if err != nil {
+		return Sample{}, fmt.Errorf("replay: %w", err)
+	}
+	contentType, err := os.ReadFile(rec.Path + contentTypeExt)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Sample{}, fmt.Errorf("replay: %w", err)
+	}
+	s, err := ParseSampleType(body, strings.TrimSpace(string(contentType)), rec.At)
+	if err != nil {
+		return Sample{}, fmt.Errorf("replay %s: %w", rec.Path, err)
+	}
+	return s, nil
+}
+
+// parseRecordingName extracts the timestamp and collision sequence of scrape-<ts>[-n].prom.
+func parseRecordingName(name string) (at time.Time, seq int, ok bool) {
+	rest, ok := strings.CutPrefix(name, recordingPrefix)
+	if !ok {
+		return time.Time{}, 0, false
+	}
+	rest, ok = strings.CutSuffix(rest, recordingExt)
+	if !ok || len(rest) < len(recordingLayout) {
+		return time.Time{}, 0, false
+	}
+	at, err := time.Parse(recordingLayout, rest[:len(recordingLayout)])
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	if suffix := rest[len(recordingLayout):]; suffix != "" {
+		n, err := strconv.Atoi(strings.TrimPrefix(suffix, "-"))
+		if err != nil || !strings.HasPrefix(suffix, "-") {
+			return time.Time{}, 0, false
+		}
+		seq = n
+	}
+	return at, seq, true
+}
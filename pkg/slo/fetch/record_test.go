@@ -0,0 +1,115 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// counterScraper serves "requests_total <n>" with n increasing by 10 per scrape.
+type counterScraper struct{ n int }
+
+func (s *counterScraper) Scrape(context.Context) ([]byte, string, error) {
+	s.n += 10
+	return []byte(fmt.Sprintf("requests_total %d\n", s.n)), "text/plain; version=0.0.4", nil
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	rec := NewRecordingFetcher(&counterScraper{}, dir)
+
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		if _, err := rec.Fetch(context.Background(), base.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	// same timestamp twice must not overwrite
+	if _, err := rec.Fetch(context.Background(), base.Add(2*time.Minute)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	replay, err := NewReplayFetcher(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if n := len(replay.Recordings()); n != 4 {
+		t.Fatalf("expected 4 recordings, got %d", n)
+	}
+	tests := []struct {
+		at   time.Time
+		want float64
+	}{
+		{at: base.Add(-time.Hour), want: 10},
+		{at: base.Add(40 * time.Second), want: 20},
+		{at: base.Add(30 * time.Second), want: 10}, // tie prefers the earlier recording
+		{at: base.Add(time.Hour), want: 40},
+	}
+	for _, tt := range tests {
+		s, err := replay.Fetch(context.Background(), tt.at)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if s.Values["requests_total"] != tt.want {
+			t.Fatalf("at %v: expected %v, got %v", tt.at, tt.want, s.Values["requests_total"])
+		}
+	}
+
+	samples, err := replay.Samples()
+	if err != nil || len(samples) != 4 || !samples[1].At.Equal(base.Add(time.Minute)) {
+		t.Fatalf("expected 4 samples in time order, got %v (%v)", samples, err)
+	}
+}
+
+// bodyScraper serves a fixed body and Content-Type.
+type bodyScraper struct {
+	body        string
+	contentType string
+}
+
+func (s bodyScraper) Scrape(context.Context) ([]byte, string, error) {
+	return []byte(s.body), s.contentType, nil
+}
+
+func TestReplayParsesByRecordedContentType(t *testing.T) {
+	const contentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{name: "complete", body: "# TYPE requests counter\nrequests_total 3\n# EOF\n"},
+		// valid classic text, but an OpenMetrics response without # EOF is truncated
+		{name: "truncated", body: "# TYPE requests counter\nrequests_total 3\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			rec := NewRecordingFetcher(bodyScraper{body: tt.body, contentType: contentType}, dir)
+			_, liveErr := rec.Fetch(context.Background(), at)
+			if (liveErr != nil) != tt.wantErr {
+				t.Fatalf("live: expected error %v, got %v", tt.wantErr, liveErr)
+			}
+
+			replay, err := NewReplayFetcher(dir)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			s, err := replay.Fetch(context.Background(), at)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("replay: expected error %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && s.Values["requests_total"] != 3 {
+				t.Fatalf("expected requests_total 3, got %v", s.Values)
+			}
+		})
+	}
+}
+
+func TestReplayWithoutRecordings(t *testing.T) {
+	if _, err := NewReplayFetcher(t.TempDir()); err == nil {
+		t.Fatalf("expected error for an empty directory")
+	}
+}
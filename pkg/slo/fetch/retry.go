@@ -0,0 +1,206 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RetryPolicy configures RetryFetcher. Zero Attempts, InitialBackoff, MaxBackoff and Multiplier
+// take the defaults of DefaultRetryPolicy; a zero Jitter means no jitter (values outside [0, 1]
+// take the default).
+type RetryPolicy struct {
+	// Attempts is the total number of tries per fetch (1 disables retries).
+	Attempts int
+	// Backoff before retry n is InitialBackoff * Multiplier^(n-1), capped at MaxBackoff,
+	// then spread by ±Jitter (a fraction, 0.2 = ±20%) so parallel specs do not retry in lockstep.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	Jitter         float64
+	// AttemptTimeout bounds one try (0 = only the caller's context).
+	AttemptTimeout time.Duration
+	// Retryable classifies errors (default IsRetryable).
+	Retryable func(error) bool
+}
+
+// DefaultRetryPolicy retries a failed fetch twice, after ~1s and ~2s.
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:       3,
+	InitialBackoff: time.Second,
+	MaxBackoff:     10 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	d := DefaultRetryPolicy
+	if p.Attempts <= 0 {
+		p.Attempts = d.Attempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = d.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = d.MaxBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = d.Multiplier
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		p.Jitter = d.Jitter
+	}
+	if p.Retryable == nil {
+		p.Retryable = IsRetryable
+	}
+	return p
+}
+
+// backoff returns the delay before retry n (n >= 1).
+func (p RetryPolicy) backoff(n int) time.Duration {
+	d := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(n-1))
+	d = math.Min(d, float64(p.MaxBackoff))
+	d *= 1 - p.Jitter + 2*p.Jitter*rand.Float64()
+	return time.Duration(d)
+}
+
+// StatusError is a non-2xx answer of a metrics endpoint.
+type StatusError struct {
+	URL    string
+	Code   int
+	Status string
+	Body   string // first bytes of the response body
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("scrape %s: unexpected status %s: %s", e.URL, e.Status, e.Body)
+}
+
+// PermanentError marks an error that retrying cannot fix (see Permanent).
+type PermanentError struct{ Err error }
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// Permanent wraps err so IsRetryable reports false, e.g. for setup errors of a fetcher.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// IsRetryable is the default classification. Network errors, timeouts of one attempt,
+// 5xx and 429 are transient; other statuses (a wrong token or path does not heal by itself),
+// permanent errors and cancellation are not. Parse and decode failures are permanent: the
+// fetchers of this package wrap them with Permanent where they happen (see ParseSample).
+func IsRetryable(err error) bool {
+	var perm *PermanentError
+	if errors.As(err, &perm) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var status *StatusError
+	if errors.As(err, &status) {
+		return status.Code >= 500 || status.Code == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// WarningReporter is implemented by fetchers that collect non-fatal problems, such as
+// scrapes that only succeeded after a retry. Engine.Execute copies them into Summary.Warnings.
+type WarningReporter interface {
+	// TakeWarnings returns the warnings collected since the last call and clears them.
+	TakeWarnings() []string
+}
+
+// RetryFetcher retries a wrapped fetcher with exponential backoff and jitter.
+// A fetch that needed retries but succeeded is reported as a warning (TakeWarnings);
+// a fetch that gave up returns the last error together with the attempt count.
+type RetryFetcher struct {
+	fetcher MetricsFetcher
+	policy  RetryPolicy
+
+	mu       sync.Mutex
+	warnings []string
+}
+
+// NewRetryFetcher wraps f; see RetryPolicy for the defaults of zero fields.
+// Make it the only retry layer: leave HTTPConfig.Retries at 0 for a wrapped HTTPFetcher,
+// or every retry of this fetcher runs the whole inner retry loop again.
+func NewRetryFetcher(f MetricsFetcher, policy RetryPolicy) *RetryFetcher {
+	return &RetryFetcher{fetcher: f, policy: policy.withDefaults()}
+}
+
+// Fetch tries the wrapped fetcher until it succeeds, the error is not retryable,
+// the attempts are used up or ctx is done.
+func (f *RetryFetcher) Fetch(ctx context.Context, at time.Time) (Sample, error) {
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		sample, err := f.fetchOnce(ctx, at)
+		if err == nil {
+			if attempt > 1 {
+				f.warn(fmt.Sprintf("fetch at %s succeeded after %d attempts (last error: %v)",
+					at.UTC().Format(time.RFC3339), attempt, lastErr))
+			}
+			return sample, nil
+		}
+		lastErr = err
+		if attempt >= f.policy.Attempts || ctx.Err() != nil || !f.policy.Retryable(err) {
+			return Sample{}, attemptsError(attempt, err)
+		}
+
+		timer := time.NewTimer(f.policy.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return Sample{}, attemptsError(attempt, err)
+		case <-timer.C:
+		}
+	}
+}
+
+func (f *RetryFetcher) fetchOnce(ctx context.Context, at time.Time) (Sample, error) {
+	if f.policy.AttemptTimeout <= 0 {
+		return f.fetcher.Fetch(ctx, at)
+	}
+	ctx, cancel := context.WithTimeout(ctx, f.policy.AttemptTimeout)
+	defer cancel()
+	return f.fetcher.Fetch(ctx, at)
+}
+
+// TakeWarnings implements WarningReporter; warnings of the wrapped fetcher are passed on.
+func (f *RetryFetcher) TakeWarnings() []string {
+	f.mu.Lock()
+	out := f.warnings
+	f.warnings = nil
+	f.mu.Unlock()
+	if r, ok := f.fetcher.(WarningReporter); ok {
+		out = append(out, r.TakeWarnings()...)
+	}
+	return out
+}
+
+// Close closes the wrapped fetcher if it holds resources (e.g. a port-forward).
+func (f *RetryFetcher) Close() {
+	if c, ok := f.fetcher.(interface{ Close() }); ok {
+		c.Close()
+	}
+}
+
+func (f *RetryFetcher) warn(msg string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.warnings = append(f.warnings, msg)
+}
+
+func attemptsError(attempts int, err error) error {
+	if attempts == 1 {
+		return err
+	}
+	return fmt.Errorf("gave up after %d attempts: %w", attempts, err)
+}
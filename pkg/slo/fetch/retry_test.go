@@ -0,0 +1,100 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flakyFetcher fails with errs in order, then succeeds.
+type flakyFetcher struct {
+	errs  []error
+	calls int
+}
+
+func (f *flakyFetcher) Fetch(_ context.Context, at time.Time) (Sample, error) {
+	f.calls++
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return Sample{}, err
+	}
+	return Sample{At: at, Values: map[string]float64{"up": 1}}, nil
+}
+
+var fastRetry = RetryPolicy{Attempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+func TestRetryFetcherRecoversAndWarns(t *testing.T) {
+	inner := &flakyFetcher{errs: []error{
+		errors.New("connection reset"),
+		&StatusError{Code: http.StatusServiceUnavailable, Status: "503 Service Unavailable"},
+	}}
+	f := NewRetryFetcher(inner, fastRetry)
+
+	s, err := f.Fetch(context.Background(), time.Now())
+	if err != nil || s.Values["up"] != 1 {
+		t.Fatalf("expected recovered sample, got %v, %v", s, err)
+	}
+	warnings := f.TakeWarnings()
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "succeeded after 3 attempts") ||
+		!strings.Contains(warnings[0], "503 Service Unavailable") {
+		t.Fatalf("unexpected warnings %v", warnings)
+	}
+	if again := f.TakeWarnings(); len(again) != 0 {
+		t.Fatalf("expected warnings to be drained, got %v", again)
+	}
+}
+
+func TestRetryFetcherGivesUp(t *testing.T) {
+	tests := []struct {
+		name  string
+		errs  []error
+		calls int
+		want  string
+	}{
+		{name: "attempts used up", errs: []error{errors.New("a"), errors.New("b"), errors.New("c")},
+			calls: 3, want: "gave up after 3 attempts: c"},
+		{name: "4xx is not retried", errs: []error{&StatusError{Code: http.StatusUnauthorized, Status: "401"}},
+			calls: 1, want: "unexpected status 401"},
+		{name: "permanent", errs: []error{Permanent(errors.New("no URL"))}, calls: 1, want: "no URL"},
+		{name: "parse error", errs: []error{parseErr(t)}, calls: 1, want: "parse float"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &flakyFetcher{errs: tt.errs}
+			_, err := NewRetryFetcher(inner, fastRetry).Fetch(context.Background(), time.Now())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got %v", tt.want, err)
+			}
+			if inner.calls != tt.calls {
+				t.Fatalf("expected %d calls, got %d", tt.calls, inner.calls)
+			}
+		})
+	}
+}
+
+// parseErr returns the error of parsing a malformed scrape.
+func parseErr(t *testing.T) error {
+	t.Helper()
+	_, err := ParseSample([]byte("requests_total one\n"), time.Now())
+	if err == nil {
+		t.Fatal("expected a parse error")
+	}
+	return err
+}
+
+func TestRetryBackoffIsCappedAndJittered(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}.withDefaults()
+	for n, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond,
+		5: 300 * time.Millisecond} {
+		for i := 0; i < 20; i++ {
+			d := p.backoff(n)
+			if d < want*8/10 || d > want*12/10 {
+				t.Fatalf("backoff(%d) = %v, want %v ±20%%", n, d, want)
+			}
+		}
+	}
+}
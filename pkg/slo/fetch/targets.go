@@ -0,0 +1,108 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yeongki/my-operator/pkg/slo/common/promkey"
+	"github.com/yeongki/my-operator/pkg/slo/fetch/promtext"
+)
+
+// Target is one scrape endpoint, typically one controller-manager pod.
+type Target struct {
+	// Instance is the value of the "instance" label added to the target's series (e.g. the pod name).
+	Instance string
+	Scraper  Scraper
+}
+
+// TargetDiscoverer lists the current targets, e.g. every ready pod behind the metrics Service.
+type TargetDiscoverer interface {
+	Targets(ctx context.Context) ([]Target, error)
+}
+
+// MultiTargetFetcher scrapes every target of a snapshot and merges them into one Sample,
+// tagging each series with promkey.InstanceLabel.
+//
+// With several replicas behind one Service, a plain scrape hits a random pod, so start and
+// end snapshots may come from different processes. Scraping all of them keeps every series
+// tied to its process: selectors aggregate across instances, and MetricRef.Leader pins an
+// input to the leader. Exact-key counter inputs no longer match tagged series; use selectors.
+type MultiTargetFetcher struct {
+	Discoverer TargetDiscoverer
+}
+
+// Fetch scrapes all targets concurrently. Any failed target fails the snapshot:
+// a partial snapshot would make the missing instance look like a counter reset.
+func (f *MultiTargetFetcher) Fetch(ctx context.Context, at time.Time) (Sample, error) {
+	targets, err := f.Discoverer.Targets(ctx)
+	if err != nil {
+		return Sample{}, fmt.Errorf("discover targets: %w", err)
+	}
+	if len(targets) == 0 {
+		return Sample{}, errors.New("discover targets: no targets")
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Instance < targets[j].Instance })
+
+	samples := make([]Sample, len(targets))
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body, contentType, err := t.Scraper.Scrape(ctx)
+			if err == nil {
+				samples[i], err = ParseSampleType(body, contentType, at)
+			}
+			if err != nil {
+				errs[i] = fmt.Errorf("instance %s: %w", t.Instance, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return Sample{}, err
+	}
+
+	out := Sample{At: at, Values: map[string]float64{}}
+	for i, t := range targets {
+		s := samples[i]
+		for k, v := range s.Values {
+			out.Values[withInstance(k, t.Instance)] = v
+		}
+		for k, ts := range s.Timestamps {
+			if out.Timestamps == nil {
+				out.Timestamps = map[string]time.Time{}
+			}
+			out.Timestamps[withInstance(k, t.Instance)] = ts
+		}
+		for k, ex := range s.Exemplars {
+			if out.Exemplars == nil {
+				out.Exemplars = map[string]promtext.Exemplar{}
+			}
+			out.Exemplars[withInstance(k, t.Instance)] = ex
+		}
+	}
+	return out, nil
+}
+
+// Close releases the discoverer's resources (e.g. port-forwards) if it has any.
+func (f *MultiTargetFetcher) Close() {
+	if c, ok := f.Discoverer.(interface{ Close() }); ok {
+		c.Close()
+	}
+}
+
+// withInstance sets the instance label of key; an instance label exposed by the target is replaced.
+func withInstance(key, instance string) string {
+	name, labels, err := promkey.Parse(key)
+	if err != nil {
+		return key
+	}
+	labels[promkey.InstanceLabel] = instance
+	return promkey.Format(name, labels)
+}
@@ -0,0 +1,66 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type staticScraper struct {
+	body string
+	err  error
+}
+
+func (s staticScraper) Scrape(context.Context) ([]byte, string, error) {
+	return []byte(s.body), "", s.err
+}
+
+type staticTargets []Target
+
+func (t staticTargets) Targets(context.Context) ([]Target, error) { return t, nil }
+
+func TestMultiTargetFetcherTagsInstances(t *testing.T) {
+	f := &MultiTargetFetcher{Discoverer: staticTargets{
+		{Instance: "pod-b", Scraper: staticScraper{body: "leader_election_master_status{name=\"op\"} 1\n" +
+			"controller_runtime_reconcile_total{result=\"error\"} 5\n"}},
+		{Instance: "pod-a", Scraper: staticScraper{body: "leader_election_master_status{name=\"op\"} 0\n" +
+			"controller_runtime_reconcile_total{instance=\"exported\",result=\"error\"} 2\n"}},
+	}}
+
+	s, err := f.Fetch(context.Background(), time.Now())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	want := map[string]float64{
+		`leader_election_master_status{instance="pod-a",name="op"}`:           0,
+		`leader_election_master_status{instance="pod-b",name="op"}`:           1,
+		`controller_runtime_reconcile_total{instance="pod-a",result="error"}`: 2,
+		`controller_runtime_reconcile_total{instance="pod-b",result="error"}`: 5,
+	}
+	if len(s.Values) != len(want) {
+		t.Fatalf("expected %v, got %v", want, s.Values)
+	}
+	for k, v := range want {
+		if got, ok := s.Values[k]; !ok || got != v {
+			t.Fatalf("expected %s=%v, got %v (%v)", k, v, got, s.Values)
+		}
+	}
+}
+
+func TestMultiTargetFetcherFailsOnAnyTarget(t *testing.T) {
+	f := &MultiTargetFetcher{Discoverer: staticTargets{
+		{Instance: "pod-a", Scraper: staticScraper{body: "up 1\n"}},
+		{Instance: "pod-b", Scraper: staticScraper{err: errors.New("connection refused")}},
+	}}
+	_, err := f.Fetch(context.Background(), time.Now())
+	if err == nil || !strings.Contains(err.Error(), "instance pod-b: connection refused") {
+		t.Fatalf("expected pod-b error, got %v", err)
+	}
+
+	empty := &MultiTargetFetcher{Discoverer: staticTargets{}}
+	if _, err := empty.Fetch(context.Background(), time.Now()); err == nil {
+		t.Fatal("expected error without targets")
+	}
+}
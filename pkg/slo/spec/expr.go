@@ -0,0 +1,420 @@
+package spec
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Expressions of ComputeDerived combine aliased inputs with + - * / and parentheses:
+//
+//	delta(errors) / max(delta(total), 1) * 100
+//	end(depth) - start(depth)
+//
+// Inputs are only read through an input function (delta, start, end, max_over_time, ...);
+// max/min/abs are plain numeric functions.
+
+// Input functions of an expression, i.e. how one aliased input is reduced over the window.
+const (
+	ExprStart        = "start"          // value in the start snapshot
+	ExprEnd          = "end"            // value in the end snapshot
+	ExprDelta        = "delta"          // reset-corrected increase (like ComputeDelta)
+	ExprMaxOverTime  = "max_over_time"  // like ComputeMax
+	ExprMinOverTime  = "min_over_time"  // like ComputeMin
+	ExprAvgOverTime  = "avg_over_time"  // like ComputeAvg
+	ExprLastOverTime = "last_over_time" // like ComputeLast
+)
+
+// ErrDivisionByZero is returned by Expr.Eval when a divisor evaluates to 0.
+var ErrDivisionByZero = errors.New("division by zero")
+
+// ExprRef is one input read by an expression, e.g. delta(errors).
+type ExprRef struct {
+	Func  string
+	Alias string
+}
+
+func (r ExprRef) String() string { return r.Func + "(" + r.Alias + ")" }
+
+// Expr is a parsed derived-SLI expression.
+type Expr struct {
+	src  string
+	root exprNode
+}
+
+// ParseExpr parses src; errors carry the byte offset of the problem.
+func ParseExpr(src string) (*Expr, error) {
+	p := &exprParser{src: src}
+	p.next()
+	root, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("unexpected %q", p.tok.text)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+func (e *Expr) String() string { return e.src }
+
+// Refs lists the inputs read by the expression, in order of first use.
+func (e *Expr) Refs() []ExprRef {
+	var out []ExprRef
+	seen := map[ExprRef]bool{}
+	e.root.walk(func(n exprNode) {
+		if r, ok := n.(refNode); ok && !seen[r.ref] {
+			seen[r.ref] = true
+			out = append(out, r.ref)
+		}
+	})
+	return out
+}
+
+// Eval evaluates the expression with the resolved inputs.
+// Every ref of Refs must be present in values.
+func (e *Expr) Eval(values map[ExprRef]float64) (float64, error) {
+	return e.root.eval(values)
+}
+
+func isInputFunc(name string) bool {
+	switch name {
+	case ExprStart, ExprEnd, ExprDelta, ExprMaxOverTime, ExprMinOverTime, ExprAvgOverTime, ExprLastOverTime:
+		return true
+	default:
+		return false
+	}
+}
+
+// ---------------------------
+// AST
+// ---------------------------
+
+type exprNode interface {
+	eval(values map[ExprRef]float64) (float64, error)
+	walk(fn func(exprNode))
+	String() string
+}
+
+type numNode struct{ v float64 }
+
+func (n numNode) eval(map[ExprRef]float64) (float64, error) { return n.v, nil }
+func (n numNode) walk(fn func(exprNode))                    { fn(n) }
+func (n numNode) String() string                            { return strconv.FormatFloat(n.v, 'g', -1, 64) }
+
+type refNode struct{ ref ExprRef }
+
+func (n refNode) eval(values map[ExprRef]float64) (float64, error) {
+	v, ok := values[n.ref]
+	if !ok {
+		return 0, fmt.Errorf("input %s not available", n.ref)
+	}
+	return v, nil
+}
+func (n refNode) walk(fn func(exprNode)) { fn(n) }
+func (n refNode) String() string         { return n.ref.String() }
+
+type negNode struct{ x exprNode }
+
+func (n negNode) eval(values map[ExprRef]float64) (float64, error) {
+	v, err := n.x.eval(values)
+	return -v, err
+}
+func (n negNode) walk(fn func(exprNode)) { fn(n); n.x.walk(fn) }
+func (n negNode) String() string         { return "-" + n.x.String() }
+
+type binNode struct {
+	op   byte
+	l, r exprNode
+}
+
+func (n binNode) eval(values map[ExprRef]float64) (float64, error) {
+	l, err := n.l.eval(values)
+	if err != nil {
+		return 0, err
+	}
+	r, err := n.r.eval(values)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case '+':
+		return l + r, nil
+	case '-':
+		return l - r, nil
+	case '*':
+		return l * r, nil
+	default:
+		if r == 0 {
+			return 0, fmt.Errorf("%w: %s", ErrDivisionByZero, n.r)
+		}
+		return l / r, nil
+	}
+}
+func (n binNode) walk(fn func(exprNode)) { fn(n); n.l.walk(fn); n.r.walk(fn) }
+func (n binNode) String() string {
+	return "(" + n.l.String() + " " + string(n.op) + " " + n.r.String() + ")"
+}
+
+type callNode struct {
+	name string
+	args []exprNode
+}
+
+func (n callNode) eval(values map[ExprRef]float64) (float64, error) {
+	args := make([]float64, 0, len(n.args))
+	for _, a := range n.args {
+		v, err := a.eval(values)
+		if err != nil {
+			return 0, err
+		}
+		args = append(args, v)
+	}
+	switch n.name {
+	case "abs":
+		return math.Abs(args[0]), nil
+	case "max":
+		out := args[0]
+		for _, v := range args[1:] {
+			out = math.Max(out, v)
+		}
+		return out, nil
+	default: // min
+		out := args[0]
+		for _, v := range args[1:] {
+			out = math.Min(out, v)
+		}
+		return out, nil
+	}
+}
+func (n callNode) walk(fn func(exprNode)) {
+	fn(n)
+	for _, a := range n.args {
+		a.walk(fn)
+	}
+}
+func (n callNode) String() string {
+	parts := make([]string, 0, len(n.args))
+	for _, a := range n.args {
+		parts = append(parts, a.String())
+	}
+	return n.name + "(" + strings.Join(parts, ", ") + ")"
+}
+
+// ---------------------------
+// parser
+// ---------------------------
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokNum
+	tokIdent
+	tokOp // + - * / ( ) ,
+	tokBad
+)
+
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+type exprParser struct {
+	src string
+	pos int
+	tok token
+}
+
+// errorf reports a problem at the current token.
+func (p *exprParser) errorf(format string, args ...any) error {
+	return p.errorAt(p.tok.pos, format, args...)
+}
+
+func (p *exprParser) errorAt(pos int, format string, args ...any) error {
+	return fmt.Errorf("expr %q: at %d: %s", p.src, pos, fmt.Sprintf(format, args...))
+}
+
+func (p *exprParser) next() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t' || p.src[p.pos] == '\n') {
+		p.pos++
+	}
+	start := p.pos
+	if p.pos >= len(p.src) {
+		p.tok = token{kind: tokEOF, pos: start}
+		return
+	}
+	c := p.src[p.pos]
+	switch {
+	case strings.IndexByte("+-*/(),", c) >= 0:
+		p.pos++
+		p.tok = token{kind: tokOp, text: string(c), pos: start}
+	case c >= '0' && c <= '9' || c == '.':
+		for p.pos < len(p.src) && isNumByte(p.src, p.pos) {
+			p.pos++
+		}
+		p.tok = token{kind: tokNum, text: p.src[start:p.pos], pos: start}
+	case isIdentByte(c, true):
+		for p.pos < len(p.src) && isIdentByte(p.src[p.pos], false) {
+			p.pos++
+		}
+		p.tok = token{kind: tokIdent, text: p.src[start:p.pos], pos: start}
+	default:
+		p.pos++
+		p.tok = token{kind: tokBad, text: string(c), pos: start}
+	}
+}
+
+func isNumByte(s string, i int) bool {
+	c := s[i]
+	switch {
+	case c >= '0' && c <= '9', c == '.', c == 'e', c == 'E':
+		return true
+	case (c == '+' || c == '-') && i > 0 && (s[i-1] == 'e' || s[i-1] == 'E'):
+		return true
+	default:
+		return false
+	}
+}
+
+func isIdentByte(c byte, first bool) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || !first && c >= '0' && c <= '9'
+}
+
+func (p *exprParser) isOp(op string) bool { return p.tok.kind == tokOp && p.tok.text == op }
+
+// sum := product (('+' | '-') product)*
+func (p *exprParser) parseSum() (exprNode, error) {
+	l, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("+") || p.isOp("-") {
+		op := p.tok.text[0]
+		p.next()
+		r, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		l = binNode{op: op, l: l, r: r}
+	}
+	return l, nil
+}
+
+// product := unary (('*' | '/') unary)*
+func (p *exprParser) parseProduct() (exprNode, error) {
+	l, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("*") || p.isOp("/") {
+		op := p.tok.text[0]
+		p.next()
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l = binNode{op: op, l: l, r: r}
+	}
+	return l, nil
+}
+
+// unary := '-' unary | primary
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.isOp("-") {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return negNode{x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+// primary := number | '(' sum ')' | call
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	switch {
+	case p.tok.kind == tokNum:
+		v, err := strconv.ParseFloat(p.tok.text, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", p.tok.text)
+		}
+		p.next()
+		return numNode{v: v}, nil
+	case p.isOp("("):
+		p.next()
+		x, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if !p.isOp(")") {
+			return nil, p.errorf("expected ')'")
+		}
+		p.next()
+		return x, nil
+	case p.tok.kind == tokIdent:
+		return p.parseCall()
+	case p.tok.kind == tokEOF:
+		return nil, p.errorf("unexpected end of expression")
+	default:
+		return nil, p.errorf("unexpected %q", p.tok.text)
+	}
+}
+
+// call := inputFunc '(' alias ')' | ('max' | 'min' | 'abs') '(' sum (',' sum)* ')'
+func (p *exprParser) parseCall() (exprNode, error) {
+	name, namePos := p.tok.text, p.tok.pos
+	p.next()
+	if !p.isOp("(") {
+		if isInputFunc(name) {
+			return nil, p.errorf("expected '(' after %s", name)
+		}
+		return nil, p.errorAt(namePos, "bare input %q: wrap it in an input function such as %s(%s)",
+			name, ExprDelta, name)
+	}
+	p.next()
+
+	if isInputFunc(name) {
+		if p.tok.kind != tokIdent {
+			return nil, p.errorf("%s expects an input alias", name)
+		}
+		alias := p.tok.text
+		p.next()
+		if !p.isOp(")") {
+			return nil, p.errorf("%s takes exactly one input alias", name)
+		}
+		p.next()
+		return refNode{ref: ExprRef{Func: name, Alias: alias}}, nil
+	}
+
+	switch name {
+	case "max", "min", "abs":
+	default:
+		return nil, p.errorAt(namePos, "unknown function %q", name)
+	}
+	var args []exprNode
+	for {
+		a, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, a)
+		if p.isOp(",") {
+			p.next()
+			continue
+		}
+		if !p.isOp(")") {
+			return nil, p.errorf("expected ',' or ')' in %s()", name)
+		}
+		p.next()
+		break
+	}
+	if name == "abs" && len(args) != 1 {
+		return nil, p.errorf("abs takes exactly one argument")
+	}
+	return callNode{name: name, args: args}, nil
+}
@@ -0,0 +1,67 @@
+package spec
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExprEval(t *testing.T) {
+	values := map[ExprRef]float64{
+		{Func: ExprDelta, Alias: "errors"}: 3,
+		{Func: ExprDelta, Alias: "total"}:  0,
+		{Func: ExprEnd, Alias: "depth"}:    7,
+		{Func: ExprStart, Alias: "depth"}:  2,
+	}
+
+	tests := []struct {
+		src  string
+		want float64
+	}{
+		{src: "delta(errors) / max(delta(total), 1) * 100", want: 300},
+		{src: "end(depth) - start(depth)", want: 5},
+		{src: "-(1 + 2) * 3 - -1", want: -8},
+		{src: "abs(start(depth) - end(depth)) / 2.5e0", want: 2},
+		{src: "min(1, 2, -3)", want: -3},
+	}
+	for _, tt := range tests {
+		e, err := ParseExpr(tt.src)
+		if err != nil {
+			t.Fatalf("%s: expected no parse error, got %v", tt.src, err)
+		}
+		got, err := e.Eval(values)
+		if err != nil || got != tt.want {
+			t.Fatalf("%s: expected %v, got %v (%v)", tt.src, tt.want, got, err)
+		}
+	}
+
+	e, _ := ParseExpr("delta(errors) / delta(total)")
+	if _, err := e.Eval(values); !errors.Is(err, ErrDivisionByZero) {
+		t.Fatalf("expected division by zero, got %v", err)
+	}
+	if refs := e.Refs(); len(refs) != 2 || refs[0].String() != "delta(errors)" {
+		t.Fatalf("unexpected refs %v", refs)
+	}
+}
+
+func TestParseExprErrors(t *testing.T) {
+	tests := []struct{ src, want string }{
+		{src: "", want: "at 0: unexpected end"},
+		{src: "errors / 2", want: "at 0: bare input"},
+		{src: "errors / total", want: `at 0: bare input "errors"`},
+		{src: "delta(errors) / total", want: `at 16: bare input "total"`},
+		{src: "delta errors", want: "at 6: expected '(' after delta"},
+		{src: "delta(errors", want: "at 12: delta takes exactly one input alias"},
+		{src: "delta(1)", want: "at 6: delta expects an input alias"},
+		{src: "1 + sqrt(delta(a))", want: "at 4: unknown function"},
+		{src: "1 +", want: "at 3: unexpected end"},
+		{src: "(1 + 2", want: "at 6: expected ')'"},
+		{src: "1 $ 2", want: `at 2: unexpected "$"`},
+	}
+	for _, tt := range tests {
+		_, err := ParseExpr(tt.src)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("%q: expected error containing %q, got %v", tt.src, tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package spec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/yeongki/my-operator/pkg/slo/common/promkey"
+)
+
+// FileVersionV1 is the only supported spec document version.
+const FileVersionV1 = "v1"
+
+// Spec documents are YAML (JSON is accepted as a YAML subset):
+//
+//	version: v1
+//	specs:
+//	  - id: reconcile_error_delta
+//	    title: reconcile error delta
+//	    unit: count
+//	    kind: delta_counter
+//	    inputs:
+//	      - key: 'controller_runtime_reconcile_total{result="error"}'
+//	      # or select several series: selector + agg (sum|max|min|count, default sum)
+//	      # - selector: 'rest_client_requests_total{code=~"5.."}'
+//	      #   agg: sum
+//	      #   leader: true   # multi-replica scrapes: only the leader's series
+//	    compute:
+//	      mode: delta
+//	    judge:
+//	      rules:
+//	        - metric: value
+//	          op: ">"
+//	          target: 0
+//	          level: fail
+//	  - id: reconcile_error_ratio
+//	    inputs:
+//	      - {selector: 'controller_runtime_reconcile_total{result="error"}', alias: errors}
+//	      - {selector: controller_runtime_reconcile_total, alias: total}
+//	    compute: {mode: ratio, numerator: errors, denominator: total}
+//	  - id: workqueue_depth_growth
+//	    inputs:
+//	      - {selector: workqueue_depth, alias: depth}
+//	    compute: {mode: derived, expr: 'end(depth) - start(depth)'}
+type fileDoc struct {
+	Version string    `yaml:"version"`
+	Specs   []specDoc `yaml:"specs"`
+}
+
+type specDoc struct {
+	ID          string     `yaml:"id"`
+	Title       string     `yaml:"title"`
+	Unit        string     `yaml:"unit"`
+	Kind        string     `yaml:"kind"`
+	Description string     `yaml:"description"`
+	Inputs      []inputDoc `yaml:"inputs"`
+	Compute     computeDoc `yaml:"compute"`
+	Judge       *judgeDoc  `yaml:"judge"`
+}
+
+type inputDoc struct {
+	Key      string `yaml:"key"`
+	Alias    string `yaml:"alias"`
+	Selector string `yaml:"selector"`
+	Agg      string `yaml:"agg"`
+	Leader   bool   `yaml:"leader"`
+}
+
+type computeDoc struct {
+	Mode        string    `yaml:"mode"`
+	Quantiles   []float64 `yaml:"quantiles"`
+	Numerator   string    `yaml:"numerator"`
+	Denominator string    `yaml:"denominator"`
+	Expr        string    `yaml:"expr"`
+}
+
+type judgeDoc struct {
+	Rules []ruleDoc `yaml:"rules"`
+}
+
+type ruleDoc struct {
+	Metric string  `yaml:"metric"`
+	Op     string  `yaml:"op"`
+	Target float64 `yaml:"target"`
+	Level  string  `yaml:"level"`
+}
+
+// Problem is one validation problem found while loading a spec document.
+type Problem struct {
+	Line int    // 1-based line in the document, 0 if unknown
+	Path string // e.g. specs[2].judge.rules[0].op
+	Msg  string
+}
+
+// LoadError reports every problem of a spec document at once.
+type LoadError struct {
+	File     string
+	Problems []Problem
+}
+
+func (e *LoadError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d problem(s)", e.File, len(e.Problems))
+	for _, p := range e.Problems {
+		b.WriteString("\n  ")
+		b.WriteString(e.File)
+		if p.Line > 0 {
+			fmt.Fprintf(&b, ":%d", p.Line)
+		}
+		if p.Path != "" {
+			fmt.Fprintf(&b, ": %s", p.Path)
+		}
+		fmt.Fprintf(&b, ": %s", p.Msg)
+	}
+	return b.String()
+}
+
+// LoadFile reads a versioned YAML/JSON spec document into SLI specs.
+func LoadFile(path string) ([]SLISpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Load(path, data)
+}
+
+// Load parses a versioned YAML/JSON spec document. name is only used in error messages.
+// All validation problems are returned together as a *LoadError with line numbers.
+func Load(name string, data []byte) ([]SLISpec, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, &LoadError{File: name, Problems: []Problem{{Msg: err.Error()}}}
+	}
+
+	var doc fileDoc
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, &LoadError{File: name, Problems: decodeProblems(err)}
+	}
+
+	l := &loader{lines: nodeLines(&root)}
+	specs := l.convert(doc)
+	if len(l.problems) > 0 {
+		return nil, &LoadError{File: name, Problems: l.problems}
+	}
+	return specs, nil
+}
+
+type loader struct {
+	lines    map[string]int
+	problems []Problem
+}
+
+func (l *loader) addf(path, format string, args ...any) {
+	l.problems = append(l.problems, Problem{Line: l.line(path), Path: path, Msg: fmt.Sprintf(format, args...)})
+}
+
+// line resolves the closest known line for path (walking up to the parent).
+func (l *loader) line(path string) int {
+	for p := path; p != ""; p = parentPath(p) {
+		if n, ok := l.lines[p]; ok {
+			return n
+		}
+	}
+	return 0
+}
+
+func (l *loader) convert(doc fileDoc) []SLISpec {
+	switch doc.Version {
+	case FileVersionV1:
+	case "":
+		l.addf("version", "version is required (supported: %s)", FileVersionV1)
+	default:
+		l.addf("version", "unsupported version %q (supported: %s)", doc.Version, FileVersionV1)
+	}
+
+	out := make([]SLISpec, 0, len(doc.Specs))
+	seen := map[string]string{}
+	for i, d := range doc.Specs {
+		path := fmt.Sprintf("specs[%d]", i)
+		reported := map[string]bool{}
+		addf := func(p, format string, args ...any) {
+			reported[p] = true
+			l.addf(p, format, args...)
+		}
+
+		id := strings.TrimSpace(d.ID)
+		if prev, dup := seen[id]; dup && id != "" {
+			addf(path+".id", "duplicate id %q (first defined at %s)", id, prev)
+		} else {
+			seen[id] = path
+		}
+
+		s := SLISpec{
+			ID:          id,
+			Title:       d.Title,
+			Unit:        d.Unit,
+			Kind:        d.Kind,
+			Description: d.Description,
+			Compute: ComputeSpec{
+				Mode:        ComputeMode(d.Compute.Mode),
+				Quantiles:   d.Compute.Quantiles,
+				Numerator:   d.Compute.Numerator,
+				Denominator: d.Compute.Denominator,
+				Expr:        d.Compute.Expr,
+			},
+		}
+
+		for _, in := range d.Inputs {
+			key := strings.TrimSpace(in.Key)
+			// canonicalize so authors may write labels in any order; bad keys are reported by Validate
+			if canonical, err := promkey.Canonicalize(key); err == nil {
+				key = canonical
+			}
+			agg := promkey.Aggregation(strings.ToLower(strings.TrimSpace(in.Agg)))
+			ref := MetricRef{Key: key, Alias: in.Alias, Agg: agg, Leader: in.Leader}
+			if sel, err := promkey.ParseSelector(in.Selector); err == nil {
+				ref.Selector = sel.String()
+			} else {
+				ref.Selector = strings.TrimSpace(in.Selector)
+			}
+			s.Inputs = append(s.Inputs, ref)
+		}
+
+		if d.Judge != nil {
+			s.Judge = &JudgeSpec{}
+			for j, r := range d.Judge.Rules {
+				var op Op
+				if err := op.UnmarshalText([]byte(r.Op)); err != nil {
+					addf(fmt.Sprintf("%s.judge.rules[%d].op", path, j), "%v", err)
+				}
+				level := Level(strings.ToLower(strings.TrimSpace(r.Level)))
+				s.Judge.Rules = append(s.Judge.Rules, Rule{Metric: r.Metric, Op: op, Target: r.Target, Level: level})
+			}
+		}
+
+		var verr *ValidationError
+		if errors.As(s.Validate(), &verr) {
+			for _, fe := range verr.Fields {
+				p := path + "." + fe.Path
+				if !reported[p] {
+					addf(p, "%s", fe.Msg)
+				}
+			}
+		}
+
+		out = append(out, s)
+	}
+	return out
+}
+
+// nodeLines maps document paths (specs[0].inputs[1].key) to the line of their value.
+func nodeLines(root *yaml.Node) map[string]int {
+	out := map[string]int{}
+	var walk func(n *yaml.Node, path string)
+	walk = func(n *yaml.Node, path string) {
+		switch n.Kind {
+		case yaml.DocumentNode:
+			for _, c := range n.Content {
+				walk(c, path)
+			}
+			return
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				k, v := n.Content[i], n.Content[i+1]
+				p := k.Value
+				if path != "" {
+					p = path + "." + k.Value
+				}
+				out[p] = k.Line
+				walk(v, p)
+			}
+		case yaml.SequenceNode:
+			for i, c := range n.Content {
+				p := path + "[" + strconv.Itoa(i) + "]"
+				out[p] = c.Line
+				walk(c, p)
+			}
+		}
+	}
+	walk(root, "")
+	return out
+}
+
+func parentPath(p string) string {
+	if strings.HasSuffix(p, "]") {
+		if i := strings.LastIndexByte(p, '['); i >= 0 {
+			return p[:i]
+		}
+	}
+	if i := strings.LastIndexByte(p, '.'); i >= 0 {
+		return p[:i]
+	}
+	return ""
+}
+
+// decodeProblems splits yaml type errors ("line 7: field foo not found ...") into problems.
+func decodeProblems(err error) []Problem {
+	var te *yaml.TypeError
+	if !errors.As(err, &te) {
+		return []Problem{{Msg: err.Error()}}
+	}
+	out := make([]Problem, 0, len(te.Errors))
+	for _, msg := range te.Errors {
+		p := Problem{Msg: msg}
+		if rest, ok := strings.CutPrefix(msg, "line "); ok {
+			if i := strings.IndexByte(rest, ':'); i > 0 {
+				if n, err := strconv.Atoi(rest[:i]); err == nil {
+					p.Line = n
+					p.Msg = strings.TrimSpace(rest[i+1:])
+				}
+			}
+		}
+		out = append(out, p)
+	}
+	return out
+}
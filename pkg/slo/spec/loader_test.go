@@ -0,0 +1,163 @@
+package spec
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLoadYAML(t *testing.T) {
+	doc := `version: v1
+specs:
+  - id: reconcile_error_delta
+    unit: count
+    inputs:
+      - key: 'controller_runtime_reconcile_total{result="error", controller="joboperator"}'
+        alias: errors
+    compute:
+      mode: delta
+    judge:
+      rules:
+        - metric: value
+          op: gt
+          target: 0
+          level: fail
+`
+	specs, err := Load("specs.yaml", []byte(doc))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(specs) != 1 {
+		t.Fatalf("expected 1 spec, got %d", len(specs))
+	}
+	s := specs[0]
+	if s.Inputs[0].Key != `controller_runtime_reconcile_total{controller="joboperator",result="error"}` {
+		t.Fatalf("expected canonical key, got %q", s.Inputs[0].Key)
+	}
+	if s.Compute.Mode != ComputeDelta || s.Judge.Rules[0].Op != OpGT || s.Judge.Rules[0].Level != LevelFail {
+		t.Fatalf("unexpected spec: %+v", s)
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	doc := `{"version": "v1", "specs": [
+  {"id": "depth", "inputs": [{"key": "workqueue_depth"}], "compute": {"mode": "max"}}
+]}`
+	specs, err := Load("specs.json", []byte(doc))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(specs) != 1 || specs[0].Compute.Mode != ComputeMax {
+		t.Fatalf("unexpected specs: %+v", specs)
+	}
+}
+
+func TestLoadSelectorInputs(t *testing.T) {
+	doc := `version: v1
+specs:
+  - id: rest_5xx
+    inputs:
+      - selector: 'rest_client_requests_total{method!="GET", code=~"5.."}'
+        agg: MAX
+    compute:
+      mode: delta
+  - id: bad
+    inputs:
+      - selector: 'rest_client_requests_total{code=~"("}'
+        agg: median
+    compute:
+      mode: delta
+`
+	_, err := Load("specs.yaml", []byte(doc))
+	var lerr *LoadError
+	if !errors.As(err, &lerr) || len(lerr.Problems) != 2 {
+		t.Fatalf("expected 2 problems, got %v", err)
+	}
+	if lerr.Problems[0].Path != "specs[1].inputs[0].selector" || lerr.Problems[1].Path != "specs[1].inputs[0].agg" {
+		t.Fatalf("unexpected problems: %+v", lerr.Problems)
+	}
+
+	specs, err := Load("specs.yaml", []byte(doc[:strings.Index(doc, "  - id: bad")]))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	in := specs[0].Inputs[0]
+	if in.Selector != `rest_client_requests_total{code=~"5..",method!="GET"}` || in.Agg != "max" {
+		t.Fatalf("expected canonical selector with max, got %+v", in)
+	}
+}
+
+func TestLoadReportsExprErrors(t *testing.T) {
+	doc := `version: v1
+specs:
+  - id: error_percent
+    inputs:
+      - {selector: 'controller_runtime_reconcile_total{result="error"}', alias: errors}
+    compute:
+      mode: derived
+      expr: 'delta(errors) / delta(total'
+`
+	_, err := Load("specs.yaml", []byte(doc))
+	var lerr *LoadError
+	if !errors.As(err, &lerr) || len(lerr.Problems) != 1 {
+		t.Fatalf("expected 1 problem, got %v", err)
+	}
+	if p := lerr.Problems[0]; p.Path != "specs[0].compute.expr" || p.Line != 8 {
+		t.Fatalf("expected expr problem at line 8, got %+v", p)
+	}
+}
+
+func TestLoadReportsAllProblemsWithLines(t *testing.T) {
+	doc := `version: v1
+specs:
+  - id: a
+    inputs:
+      - key: 'broken{result="error"'
+    compute:
+      mode: delta
+  - id: a
+    inputs:
+      - key: ok_total
+    compute:
+      mode: median
+    judge:
+      rules:
+        - op: "~"
+          target: 1
+          level: crit
+`
+	_, err := Load("specs.yaml", []byte(doc))
+	var le *LoadError
+	if !errors.As(err, &le) {
+		t.Fatalf("expected *LoadError, got %v", err)
+	}
+
+	want := map[string]int{
+		"specs[0].inputs[0].key":        5,
+		"specs[1].id":                   8,
+		"specs[1].compute.mode":         12,
+		"specs[1].judge.rules[0].op":    15,
+		"specs[1].judge.rules[0].level": 17,
+	}
+	if len(le.Problems) != len(want) {
+		t.Fatalf("expected %d problems, got %d:\n%v", len(want), len(le.Problems), err)
+	}
+	for _, p := range le.Problems {
+		line, ok := want[p.Path]
+		if !ok || line != p.Line {
+			t.Fatalf("unexpected problem %+v (want line %d)", p, line)
+		}
+	}
+	if !strings.Contains(err.Error(), "specs.yaml:8: specs[1].id: duplicate id") {
+		t.Fatalf("expected file:line in message, got:\n%v", err)
+	}
+}
+
+func TestLoadRejectsUnknownFields(t *testing.T) {
+	doc := "version: v1\nspecs:\n  - id: a\n    inputz: []\n"
+	_, err := Load("specs.yaml", []byte(doc))
+	var le *LoadError
+	if !errors.As(err, &le) || le.Problems[0].Line != 4 {
+		t.Fatalf("expected unknown field at line 4, got %v", err)
+	}
+}
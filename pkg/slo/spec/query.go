@@ -0,0 +1,42 @@
+package spec
+
+import "github.com/yeongki/my-operator/pkg/slo/common/promkey"
+
+// QuerySelectors returns the PromQL series selectors that cover every input of specs,
+// deduplicated in first-use order. Fetchers that query a Prometheus server (instead of
+// scraping the full /metrics page) use them to ask only for what the specs read.
+// Histogram inputs select their "<name>_bucket" series.
+func QuerySelectors(specs []SLISpec) []string {
+	var out []string
+	seen := map[string]bool{}
+	add := func(q string) {
+		if q != "" && !seen[q] {
+			seen[q] = true
+			out = append(out, q)
+		}
+	}
+
+	for _, s := range specs {
+		for _, in := range s.Inputs {
+			q := in.Selector
+			if q == "" {
+				q = in.Key
+			}
+			if s.Compute.Mode == ComputeHistogramQuantile {
+				q = bucketSelector(q)
+			}
+			add(q)
+		}
+	}
+	return out
+}
+
+// bucketSelector turns `name{...}` into `name_bucket{...}`. Invalid selectors are returned as is.
+func bucketSelector(q string) string {
+	sel, err := promkey.ParseSelector(q)
+	if err != nil {
+		return q
+	}
+	sel.Name += "_bucket"
+	return sel.String()
+}
@@ -0,0 +1,30 @@
+package spec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuerySelectors(t *testing.T) {
+	specs := []SLISpec{
+		{
+			ID:      "a",
+			Inputs:  []MetricRef{PromMetric("x_total", Labels{"code": "200"})},
+			Compute: ComputeSpec{Mode: ComputeDelta},
+		},
+		{
+			ID:      "b",
+			Inputs:  []MetricRef{{Selector: `x_total{code="200"}`}},
+			Compute: ComputeSpec{Mode: ComputeDelta},
+		},
+		{
+			ID:      "c",
+			Inputs:  []MetricRef{{Selector: `lat_seconds{op=~"get|list"}`}},
+			Compute: ComputeSpec{Mode: ComputeHistogramQuantile},
+		},
+	}
+	want := []string{`x_total{code="200"}`, `lat_seconds_bucket{op=~"get|list"}`}
+	if got := QuerySelectors(specs); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
@@ -4,6 +4,7 @@ import "fmt"
 
 type Registry struct {
 	items map[string]SLISpec
+	order []string // registration order, List() follows it
 }
 
 func NewRegistry() *Registry {
@@ -18,6 +19,7 @@ func (r *Registry) Register(s SLISpec) error {
 		return fmt.Errorf("sli spec already registered: %s", s.ID)
 	}
 	r.items[s.ID] = s
+	r.order = append(r.order, s.ID)
 	return nil
 }
 
@@ -27,15 +29,32 @@ func (r *Registry) MustRegister(s SLISpec) {
 	}
 }
 
+// Override replaces an already registered spec (same ID), keeping its position.
+// It is how a layered preset (e.g. an operator preset on top of a baseline) swaps a spec.
+func (r *Registry) Override(s SLISpec) error {
+	if _, exists := r.items[s.ID]; !exists {
+		return fmt.Errorf("sli spec not registered (cannot override): %s", s.ID)
+	}
+	r.items[s.ID] = s
+	return nil
+}
+
+func (r *Registry) MustOverride(s SLISpec) {
+	if err := r.Override(s); err != nil {
+		panic(err)
+	}
+}
+
 func (r *Registry) Get(id string) (SLISpec, bool) {
 	s, ok := r.items[id]
 	return s, ok
 }
 
+// List returns the registered specs in registration order.
 func (r *Registry) List() []SLISpec {
-	out := make([]SLISpec, 0, len(r.items))
-	for _, s := range r.items {
-		out = append(out, s)
+	out := make([]SLISpec, 0, len(r.order))
+	for _, id := range r.order {
+		out = append(out, r.items[id])
 	}
 	return out
 }
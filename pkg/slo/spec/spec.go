@@ -2,6 +2,8 @@ package spec
 
 import (
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 
 	"github.com/yeongki/my-operator/pkg/slo/common/promkey"
@@ -10,9 +12,21 @@ import (
 // MetricRef identifies a metric input to an SLI.
 // v3: simplest form uses a canonical Prometheus "text key" string.
 // Example: controller_runtime_reconcile_total{result="success"}
+// A key without labels (e.g. workqueue_depth) sums every series of the name, as in v3.
+//
+// Selector picks a subset of series with PromQL-style matchers instead of one exact key,
+// and Agg combines them. Example: rest_client_requests_total{code=~"5.."} with AggSum.
+// Exactly one of Key and Selector is set.
+//
+// When every replica is scraped (series carry an "instance" label), a selector aggregates
+// across instances; Leader pins it to the replica holding the leader election lease instead.
 type MetricRef struct {
 	Key   string
 	Alias string // optional
+
+	Selector string
+	Agg      promkey.Aggregation // sum (default) | max | min | count; Selector only
+	Leader   bool                // only series of the leader instance; Selector only
 }
 
 func UnsafePromKey(key string) MetricRef { return MetricRef{Key: key} }
@@ -23,17 +37,87 @@ func PromMetric(name string, labels Labels) MetricRef {
 	return MetricRef{Key: promkey.Format(name, map[string]string(labels))}
 }
 
-// TODO spec_v4 참고 향후 통합될 예정임.
+// PromSelector selects every series matching selector (e.g. `workqueue_depth{name=~"job.*"}`)
+// and combines them with agg ("" means sum).
+func PromSelector(selector string, agg promkey.Aggregation) MetricRef {
+	return MetricRef{Selector: selector, Agg: agg}
+}
+
+// String identifies the input in results: the key, or the selector wrapped in a non-sum aggregation,
+// suffixed with "@leader" when pinned to the leader.
+func (m MetricRef) String() string {
+	if m.Selector == "" {
+		return m.Key
+	}
+	s := m.Selector
+	if m.Agg != "" && m.Agg != promkey.AggSum {
+		s = string(m.Agg) + "(" + s + ")"
+	}
+	if m.Leader {
+		s += "@leader"
+	}
+	return s
+}
+
+// ComputeMode selects which snapshot(s) an SLI is computed from.
+// v3 and v4 modes share this type (see spec_v4.go).
 type ComputeMode string
 
 const (
-	ComputeSingle ComputeMode = "single" // use start snapshot only
+	ComputeSingle ComputeMode = "single" // v3: use start snapshot only (inputs must exist in both snapshots)
+	ComputeStart  ComputeMode = "start"  // v4: start snapshot
+	ComputeEnd    ComputeMode = "end"    // v4: end snapshot
 	ComputeDelta  ComputeMode = "delta"  // end - start
+
+	// Window modes aggregate every snapshot in the window (start, sampler samples, end).
+	// They fit gauges such as workqueue_depth; without a sampler only start/end exist.
+	ComputeMax  ComputeMode = "max"  // max over window
+	ComputeMin  ComputeMode = "min"  // min over window
+	ComputeAvg  ComputeMode = "avg"  // mean of the samples in the window
+	ComputeLast ComputeMode = "last" // last value seen in the window
+
+	// ComputeHistogramQuantile subtracts start/end bucket snapshots of a histogram
+	// and interpolates quantiles like PromQL histogram_quantile.
+	// Input key is the histogram base name (without _bucket), labels select the series.
+	ComputeHistogramQuantile ComputeMode = "histogram_quantile"
+
+	// ComputeRatio divides the increase of the Numerator input by the increase of the
+	// Denominator input (both reset-corrected like delta), e.g. error ratio = errors / all.
+	// Inputs are named by MetricRef.Alias; inputs that are neither side are rejected.
+	ComputeRatio ComputeMode = "ratio"
+
+	// ComputeDerived evaluates ComputeSpec.Expr over aliased inputs (see expr.go).
+	ComputeDerived ComputeMode = "derived"
 )
 
+// DefaultQuantiles is used by ComputeHistogramQuantile when Quantiles is empty.
+var DefaultQuantiles = []float64{0.5, 0.9, 0.99}
+
 // ComputeSpec describes how to compute the SLI.
 type ComputeSpec struct {
 	Mode ComputeMode
+
+	// Quantiles is used by ComputeHistogramQuantile only.
+	// Each quantile is written to SLIResult.Fields as QuantileField(q), e.g. 0.99 -> "p99".
+	Quantiles []float64
+
+	// Numerator and Denominator are input aliases, used by ComputeRatio only.
+	Numerator   string
+	Denominator string
+
+	// Expr is used by ComputeDerived only, e.g. "delta(errors) / max(delta(total), 1) * 100".
+	Expr string
+}
+
+// Result fields written by ComputeRatio next to Value.
+const (
+	FieldNumerator   = "numerator"
+	FieldDenominator = "denominator"
+)
+
+// QuantileField returns the result field name for quantile q (0.5 -> "p50", 0.999 -> "p99.9").
+func QuantileField(q float64) string {
+	return "p" + strconv.FormatFloat(math.Round(q*1e6)/1e4, 'f', -1, 64)
 }
 
 type Level string
@@ -64,12 +148,15 @@ func (o *Op) UnmarshalText(text []byte) error {
 
 // Rule is a tiny evaluation rule for v3.
 type Rule struct {
-	Metric string  // usually "value" for v3
+	Metric string  // "value" (or empty) for v3, or a result field such as "p99"
 	Op     Op      // OpLE/OpGE/...
 	Target float64 // threshold
 	Level  Level   // LevelWarn | LevelFail
 }
 
+// MetricValue is the Rule.Metric that targets SLIResult.Value.
+const MetricValue = "value"
+
 type JudgeSpec struct {
 	Rules []Rule
 }
@@ -1,10 +1,11 @@
 package spec
 
 // V4ComputeMode defines the v4 compute mode.
-type V4ComputeMode string
+// It is an alias of ComputeMode so v4 modes can be used directly in ComputeSpec.
+type V4ComputeMode = ComputeMode
 
 const (
-	V4ComputeStart V4ComputeMode = "start"
-	V4ComputeEnd   V4ComputeMode = "end"
-	V4ComputeDelta V4ComputeMode = "delta"
+	V4ComputeStart = ComputeStart
+	V4ComputeEnd   = ComputeEnd
+	V4ComputeDelta = ComputeDelta
 )
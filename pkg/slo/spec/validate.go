@@ -0,0 +1,241 @@
+package spec
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yeongki/my-operator/pkg/slo/common/promkey"
+)
+
+// FieldError is one problem of an SLISpec field.
+// Path is relative to the spec, e.g. "inputs[0].key" or "judge.rules[1].level".
+type FieldError struct {
+	Path string
+	Msg  string
+}
+
+func (e FieldError) Error() string {
+	if e.Path == "" {
+		return e.Msg
+	}
+	return e.Path + ": " + e.Msg
+}
+
+// ValidationError collects every problem of one SLISpec.
+type ValidationError struct {
+	ID     string
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Error())
+	}
+	id := e.ID
+	if id == "" {
+		id = "<no id>"
+	}
+	return fmt.Sprintf("invalid sli spec %s: %s", id, strings.Join(msgs, "; "))
+}
+
+// Validate checks the spec and returns a *ValidationError listing every problem, or nil.
+func (s SLISpec) Validate() error {
+	var errs []FieldError
+	add := func(path, format string, args ...any) {
+		errs = append(errs, FieldError{Path: path, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	switch id := s.ID; {
+	case strings.TrimSpace(id) == "":
+		add("id", "id is required")
+	case strings.ContainsAny(id, " \t\r\n"):
+		add("id", "id must not contain whitespace: %q", id)
+	}
+
+	if len(s.Inputs) == 0 {
+		add("inputs", "at least one input is required")
+	}
+	for i, in := range s.Inputs {
+		path := fmt.Sprintf("inputs[%d]", i)
+		hasKey, hasSelector := strings.TrimSpace(in.Key) != "", strings.TrimSpace(in.Selector) != ""
+		switch {
+		case hasKey && hasSelector:
+			add(path, "set either key or selector, not both")
+		case hasSelector:
+			validateSelector(add, path, in, s.Compute.Mode)
+		case !hasKey:
+			add(path+".key", "key is required")
+		default:
+			if in.Agg != "" {
+				add(path+".agg", "agg requires a selector")
+			}
+			if in.Leader {
+				add(path+".leader", "leader requires a selector")
+			}
+			canonical, err := promkey.Canonicalize(in.Key)
+			if err != nil {
+				add(path+".key", "invalid metric key: %v", err)
+				continue
+			}
+			if canonical != in.Key {
+				// snapshots are keyed by canonical form, a non-canonical key never matches
+				add(path+".key", "metric key is not canonical: %q (want %q)", in.Key, canonical)
+			}
+		}
+	}
+
+	aliases := map[string]bool{}
+	for i, in := range s.Inputs {
+		if in.Alias == "" {
+			continue
+		}
+		if aliases[in.Alias] {
+			add(fmt.Sprintf("inputs[%d].alias", i), "duplicate alias %q", in.Alias)
+		}
+		aliases[in.Alias] = true
+	}
+
+	if !knownComputeMode(s.Compute.Mode) {
+		add("compute.mode", "unknown compute mode %q", s.Compute.Mode)
+	}
+	if s.Compute.Mode == ComputeRatio {
+		for _, side := range []struct{ path, alias string }{
+			{"compute.numerator", s.Compute.Numerator},
+			{"compute.denominator", s.Compute.Denominator},
+		} {
+			switch {
+			case side.alias == "":
+				add(side.path, "%s requires an input alias", ComputeRatio)
+			case !aliases[side.alias]:
+				add(side.path, "no input with alias %q", side.alias)
+			}
+		}
+		// ratio reads only its two sides: any other input is a mistake in the spec
+		for i, in := range s.Inputs {
+			if in.Alias == "" || in.Alias != s.Compute.Numerator && in.Alias != s.Compute.Denominator {
+				add(fmt.Sprintf("inputs[%d]", i), "input %s is neither the numerator nor the denominator of %s",
+					in, ComputeRatio)
+			}
+		}
+	}
+	if s.Compute.Mode == ComputeDerived {
+		validateExpr(add, s.Compute.Expr, aliases)
+	}
+	for i, q := range s.Compute.Quantiles {
+		if q < 0 || q > 1 {
+			add(fmt.Sprintf("compute.quantiles[%d]", i), "quantile must be within [0, 1]: %v", q)
+		}
+	}
+
+	if s.Judge != nil {
+		metrics := s.ResultMetrics()
+		for i, r := range s.Judge.Rules {
+			path := fmt.Sprintf("judge.rules[%d]", i)
+			if op, ok := NormalizeOp(string(r.Op)); !ok || op != r.Op {
+				add(path+".op", "unknown op %q (use one of %s %s %s %s %s)", r.Op, OpLE, OpGE, OpLT, OpGT, OpEQ)
+			}
+			if r.Level != LevelWarn && r.Level != LevelFail {
+				add(path+".level", "unknown level %q (want %s|%s)", r.Level, LevelWarn, LevelFail)
+			}
+			metric := r.Metric
+			if metric == "" {
+				metric = MetricValue
+			}
+			if !contains(metrics, metric) {
+				add(path+".metric", "metric %q is not produced by compute mode %q (want one of %s)",
+					metric, s.Compute.Mode, strings.Join(metrics, ", "))
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{ID: s.ID, Fields: errs}
+}
+
+// ResultMetrics lists the names judge rules may target for this spec's compute mode:
+// "value" for scalar modes, plus "numerator"/"denominator" for ratio,
+// "count" and the quantile fields for histogram_quantile.
+func (s SLISpec) ResultMetrics() []string {
+	switch s.Compute.Mode {
+	case ComputeHistogramQuantile:
+	case ComputeRatio:
+		return []string{MetricValue, FieldNumerator, FieldDenominator}
+	default:
+		return []string{MetricValue}
+	}
+	quantiles := s.Compute.Quantiles
+	if len(quantiles) == 0 {
+		quantiles = DefaultQuantiles
+	}
+	out := []string{"count"}
+	for _, q := range quantiles {
+		out = append(out, QuantileField(q))
+	}
+	return out
+}
+
+// Validate checks every registered spec and returns all problems at once (errors.Join), or nil.
+func (r *Registry) Validate() error {
+	var errs []error
+	for _, s := range r.List() {
+		if err := s.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validateExpr(add func(path, format string, args ...any), src string, aliases map[string]bool) {
+	if strings.TrimSpace(src) == "" {
+		add("compute.expr", "%s requires an expression", ComputeDerived)
+		return
+	}
+	e, err := ParseExpr(src)
+	if err != nil {
+		add("compute.expr", "%v", err)
+		return
+	}
+	for _, ref := range e.Refs() {
+		if !aliases[ref.Alias] {
+			add("compute.expr", "no input with alias %q (used by %s)", ref.Alias, ref)
+		}
+	}
+}
+
+func validateSelector(add func(path, format string, args ...any), path string, in MetricRef, mode ComputeMode) {
+	if _, err := promkey.ParseSelector(in.Selector); err != nil {
+		add(path+".selector", "invalid selector: %v", err)
+	}
+	switch {
+	case !in.Agg.Known():
+		add(path+".agg", "unknown agg %q (want %s|%s|%s|%s)",
+			in.Agg, promkey.AggSum, promkey.AggMax, promkey.AggMin, promkey.AggCount)
+	case mode == ComputeHistogramQuantile && in.Agg != "" && in.Agg != promkey.AggSum:
+		// buckets are always summed by le
+		add(path+".agg", "agg %q is not supported by %s (buckets are summed)", in.Agg, mode)
+	}
+}
+
+func knownComputeMode(m ComputeMode) bool {
+	switch m {
+	case ComputeSingle, ComputeStart, ComputeEnd, ComputeDelta,
+		ComputeMax, ComputeMin, ComputeAvg, ComputeLast,
+		ComputeHistogramQuantile, ComputeRatio, ComputeDerived:
+		return true
+	default:
+		return false
+	}
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,320 @@
+package summary
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RunReportSchemaVersion is the schema of RunReport artifacts.
+const RunReportSchemaVersion = "slo.run.v1"
+
+// MaxOffenders caps RunReport.WorstOffenders.
+const MaxOffenders = 10
+
+// RunReport merges the per-test summaries of one run (see Aggregate).
+type RunReport struct {
+	SchemaVersion string    `json:"schemaVersion"`
+	GeneratedAt   time.Time `json:"generatedAt"`
+	RunID         string    `json:"runId,omitempty"`
+
+	// Files lists the summary artifacts merged by AggregateDir.
+	Files     []string `json:"files,omitempty"`
+	Summaries int      `json:"summaries"`
+
+	Totals         map[Status]int `json:"totals"`
+	SLIs           []SLIStats     `json:"slis"`
+	WorstOffenders []Offender     `json:"worstOffenders,omitempty"`
+
+	// Warnings are problems of the aggregation itself (unreadable files), not of the summaries.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// SLIStats describes one SLI across the test cases of a run.
+// Min/Max/Mean cover results with a value; Count covers all results.
+type SLIStats struct {
+	ID       string         `json:"id"`
+	Title    string         `json:"title,omitempty"`
+	Unit     string         `json:"unit,omitempty"`
+	Count    int            `json:"count"`
+	Statuses map[Status]int `json:"statuses"`
+
+	Values      int      `json:"values"`
+	Min         *float64 `json:"min,omitempty"`
+	Max         *float64 `json:"max,omitempty"`
+	Mean        *float64 `json:"mean,omitempty"`
+	MinTestCase string   `json:"minTestCase,omitempty"`
+	MaxTestCase string   `json:"maxTestCase,omitempty"`
+}
+
+// Offender is a result without a passing verdict, ranked in RunReport.WorstOffenders.
+type Offender struct {
+	TestCase   string     `json:"testCase"`
+	ID         string     `json:"id"`
+	Status     Status     `json:"status"`
+	Unit       string     `json:"unit,omitempty"`
+	Value      *float64   `json:"value,omitempty"`
+	Reason     string     `json:"reason,omitempty"`
+	ReasonCode ReasonCode `json:"reasonCode,omitempty"`
+}
+
+// Aggregate merges summaries into one run report.
+//
+// Worst offenders are the fail, data_invalid, insufficient_data and warn results, worst status
+// first; within a status, results further from the mean of their SLI come first.
+func Aggregate(runID string, summaries []Summary) RunReport {
+	rep := RunReport{
+		SchemaVersion: RunReportSchemaVersion,
+		GeneratedAt:   time.Now().UTC(),
+		RunID:         runID,
+		Summaries:     len(summaries),
+		Totals:        map[Status]int{},
+	}
+
+	index := map[string]int{}
+	sums := map[string]float64{}
+	var offenders []Offender
+	for _, s := range summaries {
+		tc := groupName(s)
+		for _, r := range s.Results {
+			rep.Totals[r.Status]++
+
+			i, ok := index[r.ID]
+			if !ok {
+				i = len(rep.SLIs)
+				index[r.ID] = i
+				rep.SLIs = append(rep.SLIs, SLIStats{ID: r.ID, Statuses: map[Status]int{}})
+			}
+			st := &rep.SLIs[i]
+			st.Count++
+			st.Statuses[r.Status]++
+			if st.Title == "" {
+				st.Title = r.Title
+			}
+			if st.Unit == "" {
+				st.Unit = r.Unit
+			}
+			if v := r.Value; v != nil && !math.IsNaN(*v) && !math.IsInf(*v, 0) {
+				st.Values++
+				sums[r.ID] += *v
+				if st.Min == nil || *v < *st.Min {
+					st.Min, st.MinTestCase = ptr(*v), tc
+				}
+				if st.Max == nil || *v > *st.Max {
+					st.Max, st.MaxTestCase = ptr(*v), tc
+				}
+			}
+
+			if offenderRank(r.Status) >= 0 {
+				offenders = append(offenders, Offender{
+					TestCase:   tc,
+					ID:         r.ID,
+					Status:     r.Status,
+					Unit:       r.Unit,
+					Value:      r.Value,
+					Reason:     r.Reason,
+					ReasonCode: r.ReasonCode,
+				})
+			}
+		}
+	}
+
+	for i := range rep.SLIs {
+		st := &rep.SLIs[i]
+		if st.Values > 0 {
+			st.Mean = ptr(sums[st.ID] / float64(st.Values))
+		}
+	}
+	sort.Slice(rep.SLIs, func(i, j int) bool { return rep.SLIs[i].ID < rep.SLIs[j].ID })
+
+	means := make(map[string]*float64, len(rep.SLIs))
+	for _, st := range rep.SLIs {
+		means[st.ID] = st.Mean
+	}
+	sort.SliceStable(offenders, func(i, j int) bool {
+		a, b := offenders[i], offenders[j]
+		if ra, rb := offenderRank(a.Status), offenderRank(b.Status); ra != rb {
+			return ra < rb
+		}
+		return deviation(a, means[a.ID]) > deviation(b, means[b.ID])
+	})
+	if len(offenders) > MaxOffenders {
+		offenders = offenders[:MaxOffenders]
+	}
+	rep.WorstOffenders = offenders
+	return rep
+}
+
+// offenderRank orders offending statuses from worst to least bad; -1 is not an offender.
+func offenderRank(st Status) int {
+	switch st {
+	case StatusFail:
+		return 0
+	case StatusDataInvalid:
+		return 1
+	case StatusInsufficientData:
+		return 2
+	case StatusWarn:
+		return 3
+	default:
+		return -1
+	}
+}
+
+// deviation is the distance of the offender's value from the SLI mean, relative to the mean
+// when it is not zero. Offenders without a value rank last within their status.
+func deviation(o Offender, mean *float64) float64 {
+	if o.Value == nil || mean == nil || math.IsNaN(*o.Value) || math.IsInf(*o.Value, 0) {
+		return -1
+	}
+	d := math.Abs(*o.Value - *mean)
+	if *mean != 0 {
+		d /= math.Abs(*mean)
+	}
+	return d
+}
+
+func ptr(v float64) *float64 { return &v }
+
+// AggregateDir reads the summary artifacts in dir that belong to runID and aggregates them.
+//
+// Summary artifacts are the sli-summary*.json files of the engine, including the "-<n>"
+// suffix added on collisions (x-1.json, or x.json-1 from older harness versions); reports written next to them (.md, .junit.xml, .html) are
+// ignored. An empty runID merges every summary. Unreadable files become report warnings:
+// one broken artifact does not hide the rest of the run.
+func AggregateDir(dir, runID string) (RunReport, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return RunReport{}, err
+	}
+
+	var (
+		files     []string
+		summaries []Summary
+		warnings  []string
+	)
+	for _, e := range entries {
+		if e.IsDir() || !isSummaryFile(e.Name()) {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		s, err := ReadFile(path)
+		if err != nil {
+			warnings = append(warnings, err.Error())
+			continue
+		}
+		if runID != "" && s.Config.RunID != runID {
+			continue
+		}
+		files = append(files, e.Name())
+		summaries = append(summaries, s)
+	}
+
+	rep := Aggregate(runID, summaries)
+	rep.Files = files
+	rep.Warnings = warnings
+	return rep, nil
+}
+
+func isSummaryFile(name string) bool {
+	if !strings.HasPrefix(name, "sli-summary") {
+		return false
+	}
+	ext := filepath.Ext(name)
+	return ext == ".json" || strings.HasPrefix(ext, ".json-")
+}
+
+// RenderRunMarkdown renders a run report: totals, per-SLI statistics and worst offenders.
+func RenderRunMarkdown(w io.Writer, rep RunReport) error {
+	var b strings.Builder
+	b.WriteString("## SLI run report\n\n")
+	if rep.RunID != "" {
+		fmt.Fprintf(&b, "_%s · %d summaries_\n\n", mdCell(rep.RunID), rep.Summaries)
+	} else {
+		fmt.Fprintf(&b, "_%d summaries_\n\n", rep.Summaries)
+	}
+
+	if totals := statusTotals(rep.Totals); len(totals) == 0 {
+		b.WriteString("No SLI results.\n")
+	} else {
+		parts := make([]string, 0, len(totals))
+		for _, t := range totals {
+			parts = append(parts, fmt.Sprintf("%s %d %s", statusIcons[t.Status], t.Count, t.Status))
+		}
+		b.WriteString(strings.TrimSpace(strings.Join(parts, " · ")) + "\n")
+	}
+
+	if len(rep.SLIs) > 0 {
+		b.WriteString("\n### SLIs\n\n")
+		b.WriteString("| SLI | Results | Statuses | Min | Mean | Max |\n")
+		b.WriteString("|---|---|---|---|---|---|\n")
+		for _, st := range rep.SLIs {
+			sli := "`" + mdCode(st.ID) + "`"
+			if st.Title != "" {
+				sli += " " + mdCell(st.Title)
+			}
+			statuses := make([]string, 0, len(st.Statuses))
+			for _, t := range statusTotals(st.Statuses) {
+				statuses = append(statuses, fmt.Sprintf("%s %d", statusIcons[t.Status], t.Count))
+			}
+			fmt.Fprintf(&b, "| %s | %d | %s | %s | %s | %s |\n", sli, st.Count, strings.Join(statuses, " "),
+				statText(st.Min, st.Unit, st.MinTestCase), statText(st.Mean, st.Unit, ""),
+				statText(st.Max, st.Unit, st.MaxTestCase))
+		}
+	}
+
+	if len(rep.WorstOffenders) > 0 {
+		b.WriteString("\n### Worst offenders\n\n")
+		b.WriteString("| Status | Test case | SLI | Value | Reason |\n")
+		b.WriteString("|---|---|---|---|---|\n")
+		for _, o := range rep.WorstOffenders {
+			r := SLIResult{Value: o.Value, Unit: o.Unit, Reason: o.Reason, ReasonCode: o.ReasonCode}
+			fmt.Fprintf(&b, "| %s %s | %s | `%s` | %s | %s |\n", statusIcons[o.Status], o.Status,
+				mdCell(o.TestCase), mdCode(o.ID), mdCell(valueText(r)), mdCell(reasonText(r)))
+		}
+	}
+
+	if len(rep.Warnings) > 0 {
+		b.WriteString("\n<details><summary>Warnings</summary>\n\n")
+		for _, warn := range rep.Warnings {
+			fmt.Fprintf(&b, "- %s\n", mdCell(warn))
+		}
+		b.WriteString("\n</details>\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func statText(v *float64, unit, testCase string) string {
+	if v == nil {
+		return "-"
+	}
+	out := FormatValue(*v, unit)
+	if testCase != "" {
+		out += " (" + testCase + ")"
+	}
+	return mdCell(out)
+}
+
+// WriteRunReport writes rep as JSON to path and as Markdown next to it (".md"),
+// each replaced atomically. Both files are attempted; the first error is returned.
+func WriteRunReport(path string, rep RunReport) error {
+	jsonErr := writeFileAtomic(path, 0o644, 0o755, true, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rep)
+	})
+	mdErr := writeFileAtomic(SinkPath(path, ".md"), 0o644, 0o755, true, func(w io.Writer) error {
+		return RenderRunMarkdown(w, rep)
+	})
+	if jsonErr != nil {
+		return jsonErr
+	}
+	return mdErr
+}
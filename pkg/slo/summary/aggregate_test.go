@@ -0,0 +1,114 @@
+package summary
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAggregate(t *testing.T) {
+	slow, fast := 2.4, 0.6
+	summaries := append(reportSummaries(), Summary{
+		Config: RunConfig{RunID: "run-1", Tags: map[string]string{"suite": "e2e", "test_case": "scales"}},
+		Results: []SLIResult{
+			{ID: "reconcile_p99", Status: StatusFail, Unit: "s", Value: &slow, ReasonCode: ReasonRuleFail},
+			{ID: "errors", Status: StatusPass, Value: &fast},
+		},
+	})
+
+	rep := Aggregate("run-1", summaries)
+	if rep.SchemaVersion != RunReportSchemaVersion || rep.Summaries != 4 {
+		t.Fatalf("unexpected report header %+v", rep)
+	}
+	if rep.Totals[StatusPass] != 3 || rep.Totals[StatusFail] != 2 || rep.Totals[StatusInsufficientData] != 1 {
+		t.Fatalf("unexpected totals %v", rep.Totals)
+	}
+	if len(rep.SLIs) != 3 || rep.SLIs[0].ID != "errors" || rep.SLIs[1].ID != "reconcile_p99" {
+		t.Fatalf("expected SLIs sorted by id, got %+v", rep.SLIs)
+	}
+	p99 := rep.SLIs[1]
+	if p99.Count != 3 || p99.Values != 2 || *p99.Min != 0.8 || *p99.Max != 2.4 || *p99.Mean != 1.6 ||
+		p99.MinTestCase != "e2e / creates pods" || p99.MaxTestCase != "e2e / scales" || p99.Unit != "s" {
+		t.Fatalf("unexpected reconcile_p99 stats %+v", p99)
+	}
+	if wq := rep.SLIs[2]; wq.Values != 0 || wq.Mean != nil || wq.Statuses[StatusInsufficientData] != 1 {
+		t.Fatalf("unexpected workqueue stats %+v", wq)
+	}
+
+	var got []string
+	for _, o := range rep.WorstOffenders {
+		got = append(got, string(o.Status)+" "+o.ID)
+	}
+	// errors (0.02) is 94% off its mean 0.31, reconcile_p99 (2.4) 50% off its mean 1.6
+	want := []string{"fail errors", "fail reconcile_p99", "insufficient_data workqueue"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected offenders %v, got %v", want, got)
+	}
+}
+
+func TestAggregateDir(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, s Summary) {
+		t.Helper()
+		if err := NewJSONFileWriter().Write(filepath.Join(dir, name), s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ss := reportSummaries()
+	write("sli-summary.v3.run-1.a.json", ss[0])
+	write("sli-summary.v3.run-1.a.json-1", ss[2])
+	write("sli-summary.v3.run-1.b.json", ss[1])
+	write("sli-summary.v3.run-2.a.json", Summary{Config: RunConfig{RunID: "run-2"}})
+	for name, body := range map[string]string{
+		"sli-summary.v3.run-1.b.md":    "## not a summary",
+		"sli-summary.v3.run-1.c.json":  "{",
+		"sli-run-report.run-1.json":    "{}",
+		"sli-summary.v3.run-1.c.other": "{}",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rep, err := AggregateDir(dir, "run-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Summaries != 3 || len(rep.Files) != 3 || rep.Totals[StatusPass] != 2 {
+		t.Fatalf("expected the three run-1 summaries, got files %v totals %v", rep.Files, rep.Totals)
+	}
+	if len(rep.Warnings) != 1 || !strings.Contains(rep.Warnings[0], "sli-summary.v3.run-1.c.json") {
+		t.Fatalf("expected the broken file as a warning, got %v", rep.Warnings)
+	}
+
+	path := filepath.Join(dir, "sli-run-report.run-1.json")
+	if err := WriteRunReport(path, rep); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back RunReport
+	if err := json.Unmarshal(raw, &back); err != nil || back.RunID != "run-1" || len(back.SLIs) != 3 {
+		t.Fatalf("unexpected JSON report (%v)\n%s", err, raw)
+	}
+	md, err := os.ReadFile(filepath.Join(dir, "sli-run-report.run-1.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"_run-1 · 3 summaries_",
+		"🔴 1 fail · 🟠 1 insufficient_data · 🟢 2 pass",
+		"| `reconcile_p99` Reconcile latency | 2 | 🟢 2 | 0.8 s (e2e / creates pods) | 0.8 s | " +
+			"0.8 s (e2e / creates pods) |",
+		"| 🔴 fail | e2e / creates pods | `errors` | 0.02 | rule fail: value &gt; 0.01 [rule_fail] |",
+		"sli-summary.v3.run-1.c.json",
+	} {
+		if !strings.Contains(string(md), want) {
+			t.Fatalf("expected %q in\n%s", want, md)
+		}
+	}
+}
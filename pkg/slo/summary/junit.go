@@ -0,0 +1,224 @@
+package summary
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultJUnitSuite names the testsuite of summaries without a "suite" tag.
+const DefaultJUnitSuite = "slo"
+
+// JUnitFileWriter writes a Summary as JUnit XML, so SLI results show up in CI test reports
+// next to the e2e results:
+//   - one testsuite, named from the "suite" tag (DefaultJUnitSuite without one)
+//   - one testcase per SLIResult, named by ID, classname from the "test_case" tag
+//   - fail → <failure>, warn → passed with a <system-out> annotation,
+//     skip, insufficient_data and data_invalid → <skipped> (measurement problems fail no build)
+//   - summary warnings → <system-out> of the testsuite
+type JUnitFileWriter struct{}
+
+func NewJUnitFileWriter() *JUnitFileWriter { return &JUnitFileWriter{} }
+
+// Write replaces path atomically; an empty path skips the write like JSONFileWriter.
+func (w *JUnitFileWriter) Write(path string, s Summary) error {
+	if path == "" {
+		return nil
+	}
+	return writeFileAtomic(path, 0o644, 0o755, true, func(out io.Writer) error {
+		return EncodeJUnit(out, s)
+	})
+}
+
+// EncodeJUnit writes s as a JUnit <testsuites> document.
+func EncodeJUnit(w io.Writer, s Summary) error {
+	doc := junitSuites{Suites: []junitSuite{junitSuiteOf(s)}}
+	doc.Tests, doc.Failures, doc.Skipped = doc.Suites[0].Tests, doc.Suites[0].Failures, doc.Suites[0].Skipped
+	doc.Name = doc.Suites[0].Name
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr,omitempty"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitCase     `xml:"testcase"`
+	SystemOut  string          `xml:"system-out,omitempty"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Body    string `xml:",chardata"`
+}
+
+func junitSuiteOf(s Summary) junitSuite {
+	name := s.Config.Tags["suite"]
+	if name == "" {
+		name = DefaultJUnitSuite
+	}
+	classname := name
+	if tc := s.Config.Tags["test_case"]; tc != "" {
+		classname = name + "." + tc
+	}
+
+	// the SLIs were measured over the run window, not individually
+	elapsed := "0"
+	if !s.Config.StartedAt.IsZero() && s.Config.FinishedAt.After(s.Config.StartedAt) {
+		elapsed = strconv.FormatFloat(s.Config.FinishedAt.Sub(s.Config.StartedAt).Seconds(), 'f', 3, 64)
+	}
+
+	suite := junitSuite{Name: name, Time: elapsed, Properties: junitProperties(s)}
+	if !s.Config.StartedAt.IsZero() {
+		suite.Timestamp = s.Config.StartedAt.UTC().Format("2006-01-02T15:04:05")
+	}
+	for _, r := range s.Results {
+		c := junitCase{Name: r.ID, Classname: classname, Time: "0"}
+		details := resultDetails(r)
+		switch r.Status {
+		case StatusFail:
+			c.Failure = &junitMessage{Message: r.Reason, Type: string(r.ReasonCode), Body: details}
+			suite.Failures++
+		case StatusWarn:
+			c.SystemOut = "WARN: " + r.Reason + "\n" + details
+		case StatusPass:
+			c.SystemOut = details
+		default:
+			c.Skipped = &junitMessage{Message: skipMessage(r)}
+			c.SystemOut = details
+			suite.Skipped++
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+	suite.Tests = len(suite.Cases)
+	if len(s.Warnings) > 0 {
+		suite.SystemOut = "warnings:\n  " + strings.Join(s.Warnings, "\n  ") + "\n"
+	}
+	return suite
+}
+
+func junitProperties(s Summary) []junitProperty {
+	props := []junitProperty{{Name: "schemaVersion", Value: s.SchemaVersion}}
+	if s.Config.RunID != "" {
+		props = append(props, junitProperty{Name: "runId", Value: s.Config.RunID})
+	}
+	keys := make([]string, 0, len(s.Config.Tags))
+	for k := range s.Config.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		props = append(props, junitProperty{Name: "tag." + k, Value: s.Config.Tags[k]})
+	}
+	return props
+}
+
+// skipMessage keeps the status visible: CI tabs only show "skipped".
+func skipMessage(r SLIResult) string {
+	msg := string(r.Status)
+	if r.ReasonCode != "" {
+		msg += " (" + string(r.ReasonCode) + ")"
+	}
+	if r.Reason != "" {
+		msg += ": " + r.Reason
+	}
+	return msg
+}
+
+// resultDetails renders the values and inputs of r as plain text lines.
+func resultDetails(r SLIResult) string {
+	var b strings.Builder
+	if r.Title != "" {
+		fmt.Fprintf(&b, "%s\n", r.Title)
+	}
+	if r.Value != nil {
+		fmt.Fprintf(&b, "value: %s\n", FormatValue(*r.Value, r.Unit))
+	}
+	fields := make([]string, 0, len(r.Fields))
+	for k := range r.Fields {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+	for _, k := range fields {
+		fmt.Fprintf(&b, "%s: %s\n", k, FormatValue(r.Fields[k], FieldUnit(k, r.Unit)))
+	}
+	if r.CounterResets > 0 {
+		fmt.Fprintf(&b, "counter resets: %d\n", r.CounterResets)
+	}
+	if len(r.InputsUsed) > 0 {
+		fmt.Fprintf(&b, "inputs used: %s\n", strings.Join(r.InputsUsed, ", "))
+	}
+	if len(r.InputsMissing) > 0 {
+		fmt.Fprintf(&b, "inputs missing: %s\n", strings.Join(r.InputsMissing, ", "))
+	}
+	if len(r.StaleSeries) > 0 {
+		fmt.Fprintf(&b, "stale series: %s\n", strings.Join(r.StaleSeries, ", "))
+	}
+	for _, ex := range r.Exemplars {
+		fmt.Fprintf(&b, "exemplar %s: %s %v\n", ex.Field, FormatValue(ex.Value, r.Unit), ex.Labels)
+	}
+	return b.String()
+}
+
+// FieldUnit returns the unit of a result field: quantile fields ("p99") share the unit of the
+// result, other fields (count, numerator, denominator) are plain counts.
+func FieldUnit(field, unit string) string {
+	q, ok := strings.CutPrefix(field, "p")
+	if !ok {
+		return ""
+	}
+	if _, err := strconv.ParseFloat(q, 64); err != nil {
+		return ""
+	}
+	return unit
+}
+
+// FormatValue formats a result value with its unit, e.g. "0.25 s" or "12".
+func FormatValue(v float64, unit string) string {
+	s := strconv.FormatFloat(v, 'g', 6, 64)
+	if unit == "" {
+		return s
+	}
+	return s + " " + unit
+}
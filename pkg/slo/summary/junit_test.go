@@ -0,0 +1,82 @@
+package summary
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJUnitFileWriter(t *testing.T) {
+	p99, slow := 0.8, 2.0
+	start := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+	s := Summary{
+		SchemaVersion: SchemaVersion,
+		Config: RunConfig{
+			RunID:      "run-1",
+			StartedAt:  start,
+			FinishedAt: start.Add(90 * time.Second),
+			Tags:       map[string]string{"suite": "joboperator-e2e", "test_case": "creates pods"},
+		},
+		Results: []SLIResult{
+			{ID: "ok", Status: StatusPass, Value: &p99, Unit: "s"},
+			{ID: "slow", Status: StatusFail, Value: &slow, Unit: "s", Reason: "rule fail: value > 1",
+				ReasonCode: ReasonRuleFail, Fields: map[string]float64{"p99": 2, "count": 12}},
+			{ID: "warm", Status: StatusWarn, Reason: "rule warn: value > 0.5", ReasonCode: ReasonRuleWarn},
+			{ID: "idle", Status: StatusInsufficientData, ReasonCode: ReasonMissingInput,
+				Reason: "missing input metrics", InputsMissing: []string{"reconcile_total"}},
+		},
+		Warnings: []string{"fetch at 2026-01-02T03:00:00Z succeeded after 2 attempts"},
+	}
+
+	path := filepath.Join(t.TempDir(), "sli-junit.xml")
+	if err := NewJUnitFileWriter().Write(path, s); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc junitSuites
+	if err := xml.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, raw)
+	}
+	if len(doc.Suites) != 1 {
+		t.Fatalf("expected one testsuite, got %d", len(doc.Suites))
+	}
+	suite := doc.Suites[0]
+	if suite.Name != "joboperator-e2e" || suite.Tests != 4 || suite.Failures != 1 || suite.Skipped != 1 ||
+		suite.Time != "90.000" {
+		t.Fatalf("unexpected testsuite %+v", suite)
+	}
+	ok, fail, warn, idle := suite.Cases[0], suite.Cases[1], suite.Cases[2], suite.Cases[3]
+	if ok.Classname != "joboperator-e2e.creates pods" || ok.Failure != nil || ok.Skipped != nil {
+		t.Fatalf("unexpected passing testcase %+v", ok)
+	}
+	if fail.Failure == nil || fail.Failure.Message != "rule fail: value > 1" ||
+		!strings.Contains(fail.Failure.Body, "p99: 2 s") || !strings.Contains(fail.Failure.Body, "count: 12\n") {
+		t.Fatalf("unexpected failing testcase %+v", fail)
+	}
+	if warn.Failure != nil || !strings.HasPrefix(warn.SystemOut, "WARN: rule warn") {
+		t.Fatalf("expected warn as system-out annotation, got %+v", warn)
+	}
+	if idle.Skipped == nil || idle.Skipped.Message != "insufficient_data (missing_input): missing input metrics" {
+		t.Fatalf("unexpected skipped testcase %+v", idle)
+	}
+	if !strings.Contains(suite.SystemOut, "succeeded after 2 attempts") {
+		t.Fatalf("expected summary warnings in system-out, got %q", suite.SystemOut)
+	}
+}
+
+func TestJUnitDefaultSuiteName(t *testing.T) {
+	var b strings.Builder
+	if err := EncodeJUnit(&b, Summary{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `<testsuite name="slo" tests="0"`) {
+		t.Fatalf("expected default suite name, got\n%s", b.String())
+	}
+}
@@ -0,0 +1,95 @@
+package summary
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// WarningWriter is a Writer that reports its own failures as warnings instead of errors.
+// Engine.Execute copies them into Summary.Warnings.
+type WarningWriter interface {
+	Writer
+	// TakeWarnings returns the warnings collected since the last call and clears them.
+	TakeWarnings() []string
+}
+
+// Sink is one destination of a MultiWriter.
+type Sink struct {
+	// Name identifies the sink in warnings, e.g. "junit".
+	Name   string
+	Writer Writer
+	// Ext replaces the extension of the summary path (".junit.xml" turns
+	// sli-summary.json into sli-summary.junit.xml). Empty keeps the path as is.
+	Ext string
+}
+
+// Standard sinks for the file writers of this package.
+func JSONSink() Sink     { return Sink{Name: "json", Writer: NewJSONFileWriter()} }
+func JUnitSink() Sink    { return Sink{Name: "junit", Writer: NewJUnitFileWriter(), Ext: ".junit.xml"} }
+func MarkdownSink() Sink { return Sink{Name: "markdown", Writer: NewMarkdownFileWriter(), Ext: ".md"} }
+func HTMLSink() Sink     { return Sink{Name: "html", Writer: NewHTMLFileWriter(), Ext: ".html"} }
+
+// MultiWriter writes a summary to several sinks. A failing sink does not stop the others:
+// measurement failure is not test failure, so Write never returns an error. Each failure
+// becomes a warning, both in the summary handed to the sinks after it and in TakeWarnings.
+// Put the sink whose artifact must list every warning (usually JSONSink) last.
+type MultiWriter struct {
+	sinks []Sink
+
+	mu       sync.Mutex
+	warnings []string
+}
+
+// NewMultiWriter writes to sinks in order.
+func NewMultiWriter(sinks ...Sink) *MultiWriter {
+	return &MultiWriter{sinks: sinks}
+}
+
+// Write writes s to every sink; see MultiWriter for failure handling.
+func (w *MultiWriter) Write(path string, s Summary) error {
+	s.Warnings = append([]string(nil), s.Warnings...)
+	for _, sink := range w.sinks {
+		if err := writeSink(sink, path, s); err != nil {
+			msg := fmt.Sprintf("summary sink %s: %v", sink.Name, err)
+			s.Warnings = append(s.Warnings, msg)
+			w.mu.Lock()
+			w.warnings = append(w.warnings, msg)
+			w.mu.Unlock()
+		}
+	}
+	return nil
+}
+
+// TakeWarnings implements WarningWriter.
+func (w *MultiWriter) TakeWarnings() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	out := w.warnings
+	w.warnings = nil
+	return out
+}
+
+// writeSink calls the sink writer, turning a panic into an error so one broken sink
+// cannot take the others (and the test) down.
+func writeSink(sink Sink, path string, s Summary) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	if sink.Writer == nil {
+		return errors.New("no writer")
+	}
+	return sink.Writer.Write(SinkPath(path, sink.Ext), s)
+}
+
+// SinkPath replaces the extension of path with ext (empty path or ext: unchanged).
+func SinkPath(path, ext string) string {
+	if path == "" || ext == "" {
+		return path
+	}
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ext
+}
@@ -0,0 +1,63 @@
+package summary
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type recordingWriter struct {
+	paths    []string
+	warnings [][]string
+}
+
+func (w *recordingWriter) Write(path string, s Summary) error {
+	w.paths = append(w.paths, path)
+	w.warnings = append(w.warnings, s.Warnings)
+	return nil
+}
+
+type failingWriter struct{ err error }
+
+func (w failingWriter) Write(string, Summary) error { return w.err }
+
+type panickingWriter struct{}
+
+func (panickingWriter) Write(string, Summary) error { panic("boom") }
+
+func TestMultiWriterIsolatesSinkFailures(t *testing.T) {
+	rec := &recordingWriter{}
+	w := NewMultiWriter(
+		Sink{Name: "junit", Writer: failingWriter{err: errors.New("disk full")}, Ext: ".junit.xml"},
+		Sink{Name: "html", Writer: panickingWriter{}, Ext: ".html"},
+		Sink{Name: "json", Writer: rec},
+	)
+
+	in := Summary{Warnings: []string{"fetch retried"}}
+	if err := w.Write("/artifacts/sli-summary.json", in); err != nil {
+		t.Fatalf("expected sink failures not to be returned, got %v", err)
+	}
+	if len(rec.paths) != 1 || rec.paths[0] != "/artifacts/sli-summary.json" {
+		t.Fatalf("expected the json sink to be written, got %v", rec.paths)
+	}
+	got := rec.warnings[0]
+	if len(got) != 3 || got[1] != "summary sink junit: disk full" ||
+		!strings.HasPrefix(got[2], "summary sink html: panic") {
+		t.Fatalf("expected earlier sink failures in the summary, got %v", got)
+	}
+	if len(in.Warnings) != 1 {
+		t.Fatalf("expected caller's summary to be untouched, got %v", in.Warnings)
+	}
+	if taken := w.TakeWarnings(); len(taken) != 2 || len(w.TakeWarnings()) != 0 {
+		t.Fatalf("expected 2 warnings taken once, got %v", taken)
+	}
+}
+
+func TestSinkPath(t *testing.T) {
+	if got := SinkPath("a/sli-summary.v4.run.case.json", ".junit.xml"); got != "a/sli-summary.v4.run.case.junit.xml" {
+		t.Fatalf("unexpected sink path %q", got)
+	}
+	if SinkPath("", ".md") != "" || SinkPath("a.json", "") != "a.json" {
+		t.Fatal("expected empty path or extension to keep the path")
+	}
+}
@@ -0,0 +1,94 @@
+package summary
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ReadFile reads a summary artifact written by any supported schema version (see Read).
+func ReadFile(path string) (Summary, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Summary{}, err
+	}
+	defer func() { _ = f.Close() }()
+	s, err := Read(f)
+	if err != nil {
+		return Summary{}, fmt.Errorf("read summary %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Read decodes a summary and migrates it to SchemaVersion, so tools comparing runs can load
+// old and new artifacts alike. Unknown schema versions are an error.
+//
+// slo.v4 files are returned as written. slo.v3 files (and files without schemaVersion, which
+// predate it) are migrated in place; MigratedFrom records the original version:
+//   - ReasonCode is derived from the v3 free-text Reason, which is kept unchanged
+//   - skip results become insufficient_data (missing inputs, zero denominator, no observations)
+//     or data_invalid (no +Inf bucket, NaN/Inf expression result); other skips stay skip
+//   - Evidence stays empty: v3 did not record input values
+//
+// A v3 summary of a failed fetch has no results, only a warning; the migration cannot
+// reconstruct per-SLI fetch_failed results from it.
+func Read(r io.Reader) (Summary, error) {
+	var s Summary
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return Summary{}, fmt.Errorf("decode summary: %w", err)
+	}
+	switch s.SchemaVersion {
+	case SchemaV4:
+		return s, nil
+	case SchemaV3, "":
+		migrateV3(&s)
+		return s, nil
+	default:
+		return Summary{}, fmt.Errorf("unsupported schema version %q (want %s or %s)",
+			s.SchemaVersion, SchemaV3, SchemaV4)
+	}
+}
+
+func migrateV3(s *Summary) {
+	s.MigratedFrom = s.SchemaVersion
+	if s.MigratedFrom == "" {
+		s.MigratedFrom = SchemaV3
+	}
+	s.SchemaVersion = SchemaVersion
+	for i := range s.Results {
+		r := &s.Results[i]
+		for _, m := range v3Reasons {
+			if !m.match(r.Reason) {
+				continue
+			}
+			r.ReasonCode = m.code
+			if r.Status == StatusSkip && m.status != "" {
+				r.Status = m.status
+			}
+			break
+		}
+	}
+}
+
+// v3Reasons maps the reasons the v3 engine wrote to v4 codes. status is the v4 status of a
+// v3 skip with that reason ("" keeps skip).
+var v3Reasons = []struct {
+	match  func(reason string) bool
+	code   ReasonCode
+	status Status
+}{
+	{equals("missing input metrics"), ReasonMissingInput, StatusInsufficientData},
+	{equals("unknown compute mode"), ReasonUnknownMode, ""},
+	// v3 did not correct resets: the delta is kept as written, with a warn
+	{equals("delta < 0 (counter reset suspected)"), ReasonCounterReset, ""},
+	{prefix("rule fail: "), ReasonRuleFail, ""},
+	{prefix("rule warn: "), ReasonRuleWarn, ""},
+}
+
+func equals(s string) func(string) bool { return func(r string) bool { return r == s } }
+
+func prefix(p string) func(string) bool {
+	return func(r string) bool { return strings.HasPrefix(r, p) }
+}
@@ -0,0 +1,89 @@
+package summary
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const v3Summary = `{
+  "schemaVersion": "slo.v3",
+  "generatedAt": "2026-01-02T03:04:05Z",
+  "config": {"runId": "run-1", "startedAt": "2026-01-02T03:00:00Z", "finishedAt": "2026-01-02T03:04:00Z",
+    "mode": {"location": "outside", "trigger": "none"}},
+  "results": [
+    {"id": "missing", "status": "skip", "reason": "missing input metrics"},
+    {"id": "mode", "status": "skip", "reason": "unknown compute mode"},
+    {"id": "errors", "status": "fail", "value": 0.2, "reason": "rule fail: value > 0.01"},
+    {"id": "busy", "status": "warn", "value": 7, "reason": "rule warn: value >= 5"},
+    {"id": "restarted", "status": "warn", "value": -12, "reason": "delta < 0 (counter reset suspected)"},
+    {"id": "ok", "status": "pass", "value": 1}
+  ]
+}`
+
+func TestReadMigratesV3(t *testing.T) {
+	s, err := Read(strings.NewReader(v3Summary))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if s.SchemaVersion != SchemaV4 || s.MigratedFrom != SchemaV3 || s.Config.RunID != "run-1" {
+		t.Fatalf("unexpected header %q/%q/%q", s.SchemaVersion, s.MigratedFrom, s.Config.RunID)
+	}
+	want := []struct {
+		status Status
+		code   ReasonCode
+	}{
+		{StatusInsufficientData, ReasonMissingInput},
+		{StatusSkip, ReasonUnknownMode},
+		{StatusFail, ReasonRuleFail},
+		{StatusWarn, ReasonRuleWarn},
+		{StatusWarn, ReasonCounterReset},
+		{StatusPass, ""},
+	}
+	for i, w := range want {
+		r := s.Results[i]
+		if r.Status != w.status || r.ReasonCode != w.code {
+			t.Fatalf("result %s: expected %s/%s, got %s/%s", r.ID, w.status, w.code, r.Status, r.ReasonCode)
+		}
+	}
+	if s.Results[0].Reason != "missing input metrics" {
+		t.Fatalf("expected v3 reason to be kept, got %q", s.Results[0].Reason)
+	}
+}
+
+func TestReadFileV4RoundTrip(t *testing.T) {
+	v := 0.2
+	in := Summary{
+		SchemaVersion: SchemaVersion,
+		GeneratedAt:   time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Results: []SLIResult{{
+			ID: "ratio", Status: StatusInsufficientData, ReasonCode: ReasonZeroDenominator,
+			Evidence: &Evidence{Samples: 2, Series: []SeriesEvidence{{Input: "m", Series: "m", End: &v}}},
+		}},
+	}
+	path := filepath.Join(t.TempDir(), "sli-summary.json")
+	if err := NewJSONFileWriter().Write(path, in); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	out, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	r := out.Results[0]
+	if out.MigratedFrom != "" || r.Status != StatusInsufficientData || r.Evidence == nil ||
+		r.Evidence.Series[0].Start != nil || *r.Evidence.Series[0].End != 0.2 {
+		t.Fatalf("unexpected round trip %+v", out)
+	}
+}
+
+func TestReadRejectsUnknownVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sli-summary.json")
+	if err := os.WriteFile(path, []byte(`{"schemaVersion": "slo.v9"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadFile(path); err == nil || !strings.Contains(err.Error(), `"slo.v9"`) {
+		t.Fatalf("expected unsupported version error, got %v", err)
+	}
+}
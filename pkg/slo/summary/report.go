@@ -0,0 +1,285 @@
+package summary
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// statusOrder lists statuses from worst to best for totals.
+var statusOrder = []Status{StatusFail, StatusDataInvalid, StatusInsufficientData, StatusWarn, StatusSkip, StatusPass}
+
+// report is the renderer-independent view of one or many summaries.
+type report struct {
+	Totals []statusCount
+	Groups []reportGroup
+}
+
+type statusCount struct {
+	Status Status
+	Count  int
+}
+
+// reportGroup is one test case: every summary tagged with the same suite and test_case.
+type reportGroup struct {
+	Name     string
+	Runs     []string // "run-1 · 2026-01-02T03:00:00Z (1m30s)"
+	Rows     []reportRow
+	Warnings []string
+}
+
+type reportRow struct {
+	Status        Status
+	ID            string
+	Title         string
+	Value         string
+	Reason        string
+	InputsUsed    []string
+	InputsMissing []string
+}
+
+func buildReport(summaries []Summary) report {
+	var rep report
+	counts := map[Status]int{}
+	index := map[string]int{}
+	for _, s := range summaries {
+		name := groupName(s)
+		i, ok := index[name]
+		if !ok {
+			i = len(rep.Groups)
+			index[name] = i
+			rep.Groups = append(rep.Groups, reportGroup{Name: name})
+		}
+		g := &rep.Groups[i]
+		g.Runs = append(g.Runs, runLine(s))
+		g.Warnings = append(g.Warnings, s.Warnings...)
+		for _, r := range s.Results {
+			counts[r.Status]++
+			g.Rows = append(g.Rows, reportRow{
+				Status:        r.Status,
+				ID:            r.ID,
+				Title:         r.Title,
+				Value:         valueText(r),
+				Reason:        reasonText(r),
+				InputsUsed:    r.InputsUsed,
+				InputsMissing: r.InputsMissing,
+			})
+		}
+	}
+	rep.Totals = statusTotals(counts)
+	return rep
+}
+
+// statusTotals orders counts like the report totals: statusOrder first, then unknown statuses.
+func statusTotals(counts map[Status]int) []statusCount {
+	out := make([]statusCount, 0, len(counts))
+	for _, st := range statusOrder {
+		if counts[st] > 0 {
+			out = append(out, statusCount{Status: st, Count: counts[st]})
+		}
+	}
+	rest := make([]string, 0, len(counts))
+	for st, n := range counts {
+		if n > 0 && statusRank(st) < 0 {
+			rest = append(rest, string(st))
+		}
+	}
+	sort.Strings(rest)
+	for _, st := range rest {
+		out = append(out, statusCount{Status: Status(st), Count: counts[Status(st)]})
+	}
+	return out
+}
+
+func statusRank(st Status) int {
+	for i, s := range statusOrder {
+		if s == st {
+			return i
+		}
+	}
+	return -1
+}
+
+// groupName is "<suite> / <test_case>" from the tags, falling back to the run id.
+func groupName(s Summary) string {
+	suite, tc := s.Config.Tags["suite"], s.Config.Tags["test_case"]
+	switch {
+	case suite != "" && tc != "":
+		return suite + " / " + tc
+	case tc != "":
+		return tc
+	case suite != "":
+		return suite
+	case s.Config.RunID != "":
+		return s.Config.RunID
+	default:
+		return "(untagged)"
+	}
+}
+
+func runLine(s Summary) string {
+	parts := make([]string, 0, 3)
+	if s.Config.RunID != "" {
+		parts = append(parts, s.Config.RunID)
+	}
+	if !s.Config.StartedAt.IsZero() {
+		line := s.Config.StartedAt.UTC().Format(time.RFC3339)
+		if s.Config.FinishedAt.After(s.Config.StartedAt) {
+			line += fmt.Sprintf(" (%s)", s.Config.FinishedAt.Sub(s.Config.StartedAt).Round(time.Second))
+		}
+		parts = append(parts, line)
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, " · ")
+}
+
+// valueText renders the value and fields with units, e.g. "0.8 s (p99 0.8 s, count 12)".
+func valueText(r SLIResult) string {
+	fields := make([]string, 0, len(r.Fields))
+	keys := make([]string, 0, len(r.Fields))
+	for k := range r.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fields = append(fields, k+" "+FormatValue(r.Fields[k], FieldUnit(k, r.Unit)))
+	}
+
+	var out string
+	if r.Value != nil {
+		out = FormatValue(*r.Value, r.Unit)
+	}
+	if len(fields) > 0 {
+		if out == "" {
+			return strings.Join(fields, ", ")
+		}
+		out += " (" + strings.Join(fields, ", ") + ")"
+	}
+	return out
+}
+
+func reasonText(r SLIResult) string {
+	if r.ReasonCode != "" && r.Reason != "" {
+		return r.Reason + " [" + string(r.ReasonCode) + "]"
+	}
+	return r.Reason + string(r.ReasonCode)
+}
+
+// statusIcons mark statuses in Markdown, where colours are not available.
+var statusIcons = map[Status]string{
+	StatusPass:             "🟢",
+	StatusWarn:             "🟡",
+	StatusFail:             "🔴",
+	StatusSkip:             "⚪",
+	StatusInsufficientData: "🟠",
+	StatusDataInvalid:      "🟣",
+}
+
+// RenderMarkdown renders summaries as Markdown tables grouped by test case, e.g. for PR comments.
+func RenderMarkdown(w io.Writer, summaries ...Summary) error {
+	rep := buildReport(summaries)
+	var b strings.Builder
+	b.WriteString("## SLI report\n\n")
+	if len(rep.Totals) == 0 {
+		b.WriteString("No SLI results.\n")
+	} else {
+		totals := make([]string, 0, len(rep.Totals))
+		for _, t := range rep.Totals {
+			totals = append(totals, fmt.Sprintf("%s %d %s", statusIcons[t.Status], t.Count, t.Status))
+		}
+		b.WriteString(strings.TrimSpace(strings.Join(totals, " · ")) + "\n")
+	}
+
+	for _, g := range rep.Groups {
+		fmt.Fprintf(&b, "\n### %s\n\n", mdCell(g.Name))
+		for _, run := range g.Runs {
+			fmt.Fprintf(&b, "_%s_  \n", mdCell(run))
+		}
+		if len(g.Rows) > 0 {
+			b.WriteString("\n| Status | SLI | Value | Inputs used | Inputs missing | Reason |\n")
+			b.WriteString("|---|---|---|---|---|---|\n")
+		}
+		for _, r := range g.Rows {
+			sli := "`" + mdCode(r.ID) + "`"
+			if r.Title != "" {
+				sli += " " + mdCell(r.Title)
+			}
+			fmt.Fprintf(&b, "| %s %s | %s | %s | %s | %s | %s |\n",
+				statusIcons[r.Status], r.Status, sli, mdCell(r.Value),
+				mdCodeList(r.InputsUsed), mdCodeList(r.InputsMissing), mdCell(r.Reason))
+		}
+		if len(g.Warnings) > 0 {
+			b.WriteString("\n<details><summary>Warnings</summary>\n\n")
+			for _, warn := range g.Warnings {
+				fmt.Fprintf(&b, "- %s\n", mdCell(warn))
+			}
+			b.WriteString("\n</details>\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mdCell keeps a value inside one table cell and keeps "<...>" from being read as HTML.
+func mdCell(s string) string {
+	return strings.NewReplacer("\n", " ", "|", `\|`, "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// mdCode makes s safe inside backticks within a table cell (code spans render "<" as is).
+func mdCode(s string) string {
+	return strings.NewReplacer("\n", " ", "|", `\|`, "`", "'").Replace(s)
+}
+
+func mdCodeList(items []string) string {
+	out := make([]string, 0, len(items))
+	for _, it := range items {
+		out = append(out, "`"+mdCode(it)+"`")
+	}
+	return strings.Join(out, "<br>")
+}
+
+//go:embed report.html.tmpl
+var reportHTML string
+
+var reportTemplate = template.Must(template.New("report").Parse(reportHTML))
+
+// RenderHTML renders summaries as a self-contained HTML page (inline CSS, no external assets).
+func RenderHTML(w io.Writer, summaries ...Summary) error {
+	return reportTemplate.Execute(w, buildReport(summaries))
+}
+
+// MarkdownFileWriter writes a Summary as a Markdown report (see RenderMarkdown).
+type MarkdownFileWriter struct{}
+
+func NewMarkdownFileWriter() *MarkdownFileWriter { return &MarkdownFileWriter{} }
+
+// Write replaces path atomically; an empty path skips the write like JSONFileWriter.
+func (w *MarkdownFileWriter) Write(path string, s Summary) error {
+	if path == "" {
+		return nil
+	}
+	return writeFileAtomic(path, 0o644, 0o755, true, func(out io.Writer) error {
+		return RenderMarkdown(out, s)
+	})
+}
+
+// HTMLFileWriter writes a Summary as a self-contained HTML report (see RenderHTML).
+type HTMLFileWriter struct{}
+
+func NewHTMLFileWriter() *HTMLFileWriter { return &HTMLFileWriter{} }
+
+// Write replaces path atomically; an empty path skips the write like JSONFileWriter.
+func (w *HTMLFileWriter) Write(path string, s Summary) error {
+	if path == "" {
+		return nil
+	}
+	return writeFileAtomic(path, 0o644, 0o755, true, func(out io.Writer) error {
+		return RenderHTML(out, s)
+	})
+}
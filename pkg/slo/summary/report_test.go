@@ -0,0 +1,82 @@
+package summary
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func reportSummaries() []Summary {
+	p99, ratio := 0.8, 0.02
+	start := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+	cfg := func(tc string) RunConfig {
+		return RunConfig{
+			RunID:      "run-1",
+			StartedAt:  start,
+			FinishedAt: start.Add(90 * time.Second),
+			Tags:       map[string]string{"suite": "e2e", "test_case": tc},
+		}
+	}
+	return []Summary{
+		{Config: cfg("creates pods"), Results: []SLIResult{
+			{ID: "reconcile_p99", Title: "Reconcile latency", Status: StatusPass, Unit: "s",
+				Value: &p99, Fields: map[string]float64{"p99": 0.8, "count": 12},
+				InputsUsed: []string{"controller_runtime_reconcile_time_seconds"}},
+			{ID: "errors", Status: StatusFail, Value: &ratio, Reason: "rule fail: value > 0.01",
+				ReasonCode: ReasonRuleFail},
+		}},
+		{Config: cfg("deletes pods"), Results: []SLIResult{
+			{ID: "workqueue", Status: StatusInsufficientData, Reason: "missing input metrics",
+				ReasonCode: ReasonMissingInput, InputsMissing: []string{`workqueue_depth{name="a|b"}`}},
+		}, Warnings: []string{"fetch(end) failed: <timeout>"}},
+		{Config: cfg("creates pods"), Results: []SLIResult{{ID: "reconcile_p99", Status: StatusPass}}},
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	var b strings.Builder
+	if err := RenderMarkdown(&b, reportSummaries()...); err != nil {
+		t.Fatal(err)
+	}
+	md := b.String()
+	for _, want := range []string{
+		"🔴 1 fail · 🟠 1 insufficient_data · 🟢 2 pass",
+		"### e2e / creates pods",
+		"_run-1 · 2026-01-02T03:00:00Z (1m30s)_",
+		"| 🟢 pass | `reconcile_p99` Reconcile latency | 0.8 s (count 12, p99 0.8 s) | " +
+			"`controller_runtime_reconcile_time_seconds` |  |  |",
+		"| 🔴 fail | `errors` | 0.02 |  |  | rule fail: value &gt; 0.01 [rule_fail] |",
+		"`workqueue_depth{name=\"a\\|b\"}`",
+		"- fetch(end) failed: &lt;timeout&gt;",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("expected %q in\n%s", want, md)
+		}
+	}
+	if strings.Count(md, "### e2e / creates pods") != 1 {
+		t.Fatalf("expected summaries of one test case to be grouped\n%s", md)
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	var b strings.Builder
+	if err := RenderHTML(&b, reportSummaries()...); err != nil {
+		t.Fatal(err)
+	}
+	page := b.String()
+	for _, want := range []string{
+		"<style>",
+		`<span class="status status-fail">fail</span>`,
+		`<span class="status status-insufficient_data">1 insufficient_data</span>`,
+		"<h2>e2e / deletes pods</h2>",
+		"0.8 s (count 12, p99 0.8 s)",
+		"fetch(end) failed: &lt;timeout&gt;",
+	} {
+		if !strings.Contains(page, want) {
+			t.Fatalf("expected %q in\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, "<script") || strings.Contains(page, "href=") || strings.Contains(page, "src=") {
+		t.Fatal("expected a self-contained page without external assets")
+	}
+}
@@ -2,14 +2,52 @@ package summary
 
 import "time"
 
+// Schema versions of Summary. SchemaVersion is the one written by the engine;
+// Read also loads SchemaV3 files (see Read for the migration).
+const (
+	SchemaV3      = "slo.v3"
+	SchemaV4      = "slo.v4"
+	SchemaVersion = SchemaV4
+)
+
 // Status is the normalized evaluation status for an SLIResult.
+//
+// pass/warn/fail are verdicts of the judge rules. The other statuses explain why there is no
+// verdict, so "nothing to measure" and "measured garbage" stay distinguishable from a spec
+// that could not be evaluated at all. None of them fails a test.
 type Status string
 
 const (
 	StatusPass Status = "pass"
 	StatusWarn Status = "warn"
 	StatusFail Status = "fail"
+	// StatusSkip: the SLI was not evaluated (invalid spec, unknown compute mode, rule on a missing field).
 	StatusSkip Status = "skip"
+	// StatusInsufficientData: not enough data in the window (missing inputs, failed fetch,
+	// zero denominator, no observations). slo.v4 only.
+	StatusInsufficientData Status = "insufficient_data"
+	// StatusDataInvalid: data was there but cannot yield a value (no +Inf bucket, NaN/Inf result).
+	// slo.v4 only.
+	StatusDataInvalid Status = "data_invalid"
+)
+
+// ReasonCode is the machine-readable counterpart of SLIResult.Reason (slo.v4).
+type ReasonCode string
+
+const (
+	ReasonMissingInput    ReasonCode = "missing_input"
+	ReasonFetchFailed     ReasonCode = "fetch_failed"
+	ReasonInvalidSpec     ReasonCode = "invalid_spec"
+	ReasonUnknownMode     ReasonCode = "unknown_compute_mode"
+	ReasonZeroDenominator ReasonCode = "zero_denominator"
+	ReasonNoObservations  ReasonCode = "no_observations"
+	ReasonNoInfBucket     ReasonCode = "no_inf_bucket"
+	ReasonInvalidValue    ReasonCode = "invalid_value"
+	ReasonRuleMetric      ReasonCode = "rule_metric_unavailable"
+	ReasonRuleFail        ReasonCode = "rule_fail"
+	ReasonRuleWarn        ReasonCode = "rule_warn"
+	ReasonCounterReset    ReasonCode = "counter_reset_corrected"
+	ReasonStaleSeries     ReasonCode = "stale_series"
 )
 
 // Summary is the contract output. All measurement methods must converge to this schema.
@@ -21,6 +59,9 @@ type Summary struct {
 
 	Results  []SLIResult `json:"results"`
 	Warnings []string    `json:"warnings,omitempty"`
+
+	// MigratedFrom is the schema version of the file Read loaded, when it was not SchemaVersion.
+	MigratedFrom string `json:"migratedFrom,omitempty"`
 }
 
 // RunConfig is embedded in the summary (so analysis tools can be method-agnostic).
@@ -48,14 +89,52 @@ type SLIResult struct {
 	Kind        string `json:"kind,omitempty"`
 	Description string `json:"description,omitempty"`
 
-	// v3: a single numeric result. Future: Fields for p50/p99 etc.
+	// v3: a single numeric result.
+	// Fields holds named results (e.g. p50/p99 for histogram_quantile); judge rules can target them.
 	Value  *float64           `json:"value,omitempty"`
 	Fields map[string]float64 `json:"fields,omitempty"`
 
-	Status Status `json:"status"` // "pass" | "warn" | "fail" | "skip"
+	Status Status `json:"status"` // "pass" | "warn" | "fail" | "skip" | "insufficient_data" | "data_invalid"
+
+	Reason     string     `json:"reason,omitempty"`
+	ReasonCode ReasonCode `json:"reasonCode,omitempty"`
 
-	Reason string `json:"reason,omitempty"`
+	// CounterResets is the number of counter resets detected (and corrected) across input series.
+	CounterResets int `json:"counterResets,omitempty"`
 
 	InputsUsed    []string `json:"inputsUsed,omitempty"`
 	InputsMissing []string `json:"inputsMissing,omitempty"`
+
+	// StaleSeries lists input series whose exposed timestamp did not advance between the
+	// start and end snapshots: the value was not updated during the window.
+	StaleSeries []string `json:"staleSeries,omitempty"`
+
+	// Exemplars point at observations behind the result, e.g. the trace of a slow reconcile.
+	Exemplars []Exemplar `json:"exemplars,omitempty"`
+
+	// Evidence holds the raw input values the result was computed from (slo.v4).
+	Evidence *Evidence `json:"evidence,omitempty"`
+}
+
+// Evidence lets a reader recheck a result without the raw scrapes.
+type Evidence struct {
+	Samples int              `json:"samples"` // snapshots in the window, start and end included
+	Series  []SeriesEvidence `json:"series,omitempty"`
+}
+
+// SeriesEvidence is one input series at the start and end of the window (nil when absent).
+type SeriesEvidence struct {
+	Input  string   `json:"input"` // MetricRef the series was read for
+	Series string   `json:"series"`
+	Start  *float64 `json:"start,omitempty"`
+	End    *float64 `json:"end,omitempty"`
+}
+
+// Exemplar is one exemplar taken from the scraped series.
+type Exemplar struct {
+	Field     string            `json:"field,omitempty"` // result field it explains, e.g. "p99"
+	Series    string            `json:"series"`          // series key the exemplar was exposed on
+	Labels    map[string]string `json:"labels"`          // e.g. trace_id
+	Value     float64           `json:"value"`           // observed value, e.g. the reconcile duration
+	Timestamp *time.Time        `json:"timestamp,omitempty"`
 }
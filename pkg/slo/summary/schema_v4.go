@@ -1,6 +1,7 @@
 package summary
 
-// EnsureV4Format sets the v4 format hint while preserving schemaVersion v3.
+// EnsureV4Format sets the v4 format hint (RunConfig.Format).
+// The hint names the measurement format and is independent of Summary.SchemaVersion.
 func EnsureV4Format(config map[string]any) map[string]any {
 	if config == nil {
 		config = map[string]any{}
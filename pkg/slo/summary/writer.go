@@ -2,6 +2,7 @@ package summary
 
 import (
 	"encoding/json"
+	"io"
 	"os"
 	"path/filepath"
 )
@@ -25,9 +26,18 @@ func (w *JSONFileWriter) Write(path string, s Summary) error {
 }
 
 // writeJSONAtomic writes JSON to a temp file in the same directory and then renames it.
+func writeJSONAtomic(path string, s Summary, fileMode, dirMode os.FileMode, doSync bool) error {
+	return writeFileAtomic(path, fileMode, dirMode, doSync, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	})
+}
+
+// writeFileAtomic writes the output of encode to a temp file in the same directory and then renames it.
 // - Atomic replace is provided by os.Rename (same filesystem).
 // - If doSync is true, it fsyncs the temp file before close for stronger durability.
-func writeJSONAtomic(path string, s Summary, fileMode, dirMode os.FileMode, doSync bool) error {
+func writeFileAtomic(path string, fileMode, dirMode os.FileMode, doSync bool, encode func(io.Writer) error) error {
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, dirMode); err != nil {
 		return err
@@ -47,9 +57,7 @@ func writeJSONAtomic(path string, s Summary, fileMode, dirMode os.FileMode, doSy
 		}
 	}()
 
-	enc := json.NewEncoder(f)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(s); err != nil {
+	if err := encode(f); err != nil {
 		return err
 	}
 
@@ -1,78 +1,228 @@
 package controller_runtime
 
-// import "github.com/yeongki/my-operator/pkg/slo/spec"
-
-// func RegisterV1(reg *spec.Registry) {
-// 	reg.MustRegister(ReconcileTotalDeltaSuccess())
-// 	reg.MustRegister(ReconcileTotalDeltaError())
-// 	reg.MustRegister(WorkqueueAddsDelta())
-// 	reg.MustRegister(WorkqueueRetriesDelta())
-// }
-
-// func ReconcileTotalDeltaSuccess() spec.SLISpec {
-// 	return spec.SLISpec{
-// 		ID:          "controller_runtime.reconcile_total_delta.success",
-// 		Title:       "Reconcile total delta (success)",
-// 		Unit:        "count",
-// 		Kind:        "delta_counter",
-// 		Description: "Delta of successful reconciliations between start/end snapshots.",
-// 		Inputs: []spec.MetricRef{
-// 			spec.PromMetric(`controller_runtime_reconcile_total{result="success"}`),
-// 		},
-// 		Compute: spec.ComputeSpec{Mode: spec.ComputeDelta},
-// 		Judge: &spec.JudgeSpec{
-// 			Rules: []spec.Rule{
-// 				{Metric: "value", Op: ">", Target: 500, Level: "warn"},
-// 				{Metric: "value", Op: ">", Target: 2000, Level: "fail"},
-// 			},
-// 		},
-// 	}
-// }
-
-// func ReconcileTotalDeltaError() spec.SLISpec {
-// 	return spec.SLISpec{
-// 		ID:          "controller_runtime.reconcile_total_delta.error",
-// 		Title:       "Reconcile total delta (error)",
-// 		Unit:        "count",
-// 		Kind:        "delta_counter",
-// 		Description: "Delta of errored reconciliations between start/end snapshots.",
-// 		Inputs: []spec.MetricRef{
-// 			spec.PromMetric(`controller_runtime_reconcile_total{result="error"}`),
-// 		},
-// 		Compute: spec.ComputeSpec{Mode: spec.ComputeDelta},
-// 		Judge: &spec.JudgeSpec{
-// 			Rules: []spec.Rule{
-// 				{Metric: "value", Op: ">", Target: 0, Level: "warn"},
-// 				{Metric: "value", Op: ">", Target: 10, Level: "fail"},
-// 			},
-// 		},
-// 	}
-// }
-
-// func WorkqueueAddsDelta() spec.SLISpec {
-// 	return spec.SLISpec{
-// 		ID:          "controller_runtime.workqueue_adds_delta",
-// 		Title:       "Workqueue adds delta",
-// 		Unit:        "count",
-// 		Kind:        "delta_counter",
-// 		Description: "Delta of workqueue adds between start/end snapshots.",
-// 		Inputs: []spec.MetricRef{
-// 			spec.PromMetric(`workqueue_adds_total{name="controller"}`),
-// 		},
-// 		Compute: spec.ComputeSpec{Mode: spec.ComputeDelta},
-// 	}
-// }
-
-// func WorkqueueRetriesDelta() spec.SLISpec {
-// 	return spec.SLISpec{
-// 		ID:          "controller_runtime.workqueue_retries_delta",
-// 		Title:       "Workqueue retries delta",
-// 		Unit:        "count",
-// 		Kind:        "delta_counter",
-// 		Description: "Delta of workqueue retries between start/end snapshots.",
-// 		Inputs: []spec.MetricRef{
-// 			spec.PromMetric(`workqueue_retries_total{name="controller"}`),
-// 		},
-// 		Compute: spec.ComputeSpec{Mode: spec.ComputeDelta},
-// 	}
-// }
+import (
+	"github.com/yeongki/my-operator/pkg/slo/common/promkey"
+	"github.com/yeongki/my-operator/pkg/slo/spec"
+)
+
+// RegisterV1 registers the baseline preset: controller-runtime reconcile + workqueue + rest-client.
+// Workqueue specs cover all queues; operator presets override them with their own queue name.
+func RegisterV1(reg *spec.Registry) {
+	for _, s := range V1() {
+		reg.MustRegister(s)
+	}
+}
+
+// V1 returns the baseline specs in registration order.
+// It has the IDs of the v3 harness specs; opt-in specs such as ReconcileErrorRatio and
+// WorkqueueDepthMax are not part of it. Two specs measure differently than in v3, so their
+// results are not comparable with v3 runs:
+//   - workqueue_depth_end reads the end snapshot (v3: single, i.e. the start snapshot).
+//   - rest_client_5xx_delta counts every 5xx code (v3: only the literal code "5xx").
+func V1() []spec.SLISpec {
+	return []spec.SLISpec{
+		ReconcileTotalDelta(),
+		ReconcileSuccessDelta(),
+		ReconcileErrorDelta(),
+		WorkqueueAddsDelta(""),
+		WorkqueueRetriesDelta(""),
+		WorkqueueDepthEnd(""),
+		RestClientRequestsDelta(),
+		RestClient429Delta(),
+		RestClient5xxDelta(),
+	}
+}
+
+// ---------------------------
+// controller-runtime reconcile
+// ---------------------------
+
+func ReconcileTotalDelta() spec.SLISpec {
+	return spec.SLISpec{
+		ID:          "reconcile_total_delta",
+		Title:       "reconcile total delta",
+		Unit:        "count",
+		Kind:        "delta_counter",
+		Description: "Delta of controller_runtime_reconcile_total during the test window (all results).",
+		Inputs: []spec.MetricRef{
+			spec.PromSelector("controller_runtime_reconcile_total", promkey.AggSum),
+		},
+		Compute: spec.ComputeSpec{Mode: spec.ComputeDelta},
+	}
+}
+
+func ReconcileSuccessDelta() spec.SLISpec {
+	return spec.SLISpec{
+		ID:          "reconcile_success_delta",
+		Title:       "reconcile success delta",
+		Unit:        "count",
+		Kind:        "delta_counter",
+		Description: `Delta of controller_runtime_reconcile_total{result="success"}.`,
+		Inputs: []spec.MetricRef{
+			spec.PromSelector(`controller_runtime_reconcile_total{result="success"}`, promkey.AggSum),
+		},
+		Compute: spec.ComputeSpec{Mode: spec.ComputeDelta},
+	}
+}
+
+func ReconcileErrorDelta() spec.SLISpec {
+	return spec.SLISpec{
+		ID:          "reconcile_error_delta",
+		Title:       "reconcile error delta",
+		Unit:        "count",
+		Kind:        "delta_counter",
+		Description: `Delta of controller_runtime_reconcile_total{result="error"}.`,
+		Inputs: []spec.MetricRef{
+			spec.PromSelector(`controller_runtime_reconcile_total{result="error"}`, promkey.AggSum),
+		},
+		Compute: spec.ComputeSpec{Mode: spec.ComputeDelta},
+		// Optional judge example: error delta should be 0
+		// Judge: &spec.JudgeSpec{Rules: []spec.Rule{{Op: spec.OpGT, Target: 0, Level: spec.LevelFail}}},
+	}
+}
+
+// ReconcileErrorRatio is opt-in (not in V1): idle windows report insufficient_data.
+func ReconcileErrorRatio() spec.SLISpec {
+	return spec.SLISpec{
+		ID:          "reconcile_error_ratio",
+		Title:       "reconcile error ratio",
+		Unit:        "ratio",
+		Kind:        "derived",
+		Description: `Share of reconciles with result="error" during the test window (insufficient_data when idle).`,
+		Inputs: []spec.MetricRef{
+			withAlias(spec.PromSelector(`controller_runtime_reconcile_total{result="error"}`, promkey.AggSum), "errors"),
+			withAlias(spec.PromSelector("controller_runtime_reconcile_total", promkey.AggSum), "total"),
+		},
+		Compute: spec.ComputeSpec{Mode: spec.ComputeRatio, Numerator: "errors", Denominator: "total"},
+	}
+}
+
+// ---------------------------
+// workqueue (controller-runtime)
+// queue == "" selects all queues (summed).
+// ---------------------------
+
+func WorkqueueAddsDelta(queue string) spec.SLISpec {
+	return spec.SLISpec{
+		ID:          "workqueue_adds_total_delta",
+		Title:       "workqueue adds total delta",
+		Unit:        "count",
+		Kind:        "delta_counter",
+		Description: "Delta of workqueue_adds_total during the test window (" + queueScope(queue) + ").",
+		Inputs: []spec.MetricRef{
+			spec.PromSelector(queueSelector("workqueue_adds_total", queue), promkey.AggSum),
+		},
+		Compute: spec.ComputeSpec{Mode: spec.ComputeDelta},
+	}
+}
+
+func WorkqueueRetriesDelta(queue string) spec.SLISpec {
+	return spec.SLISpec{
+		ID:          "workqueue_retries_total_delta",
+		Title:       "workqueue retries total delta",
+		Unit:        "count",
+		Kind:        "delta_counter",
+		Description: "Delta of workqueue_retries_total during the test window (" + queueScope(queue) + ").",
+		Inputs: []spec.MetricRef{
+			spec.PromSelector(queueSelector("workqueue_retries_total", queue), promkey.AggSum),
+		},
+		Compute: spec.ComputeSpec{Mode: spec.ComputeDelta},
+	}
+}
+
+// WorkqueueDepthEnd reads the end snapshot; the v3 harness used single (the start snapshot).
+func WorkqueueDepthEnd(queue string) spec.SLISpec {
+	return spec.SLISpec{
+		ID:          "workqueue_depth_end",
+		Title:       "workqueue depth at end",
+		Unit:        "items",
+		Kind:        "gauge",
+		Description: "workqueue_depth gauge snapshot at the end time (" + queueScope(queue) + ").",
+		Inputs: []spec.MetricRef{
+			spec.PromSelector(queueSelector("workqueue_depth", queue), promkey.AggSum),
+		},
+		Compute: spec.ComputeSpec{Mode: spec.ComputeEnd},
+	}
+}
+
+// WorkqueueDepthMax is opt-in (not in V1): register it when the session samples the window.
+func WorkqueueDepthMax(queue string) spec.SLISpec {
+	return spec.SLISpec{
+		ID:    "workqueue_depth_max",
+		Title: "workqueue depth peak",
+		Unit:  "items",
+		Kind:  "gauge",
+		Description: "Peak workqueue_depth over the test window (" + queueScope(queue) + "). " +
+			"Needs a sampler to see inside the window.",
+		Inputs: []spec.MetricRef{
+			spec.PromSelector(queueSelector("workqueue_depth", queue), promkey.AggSum),
+		},
+		Compute: spec.ComputeSpec{Mode: spec.ComputeMax},
+	}
+}
+
+func withAlias(ref spec.MetricRef, alias string) spec.MetricRef {
+	ref.Alias = alias
+	return ref
+}
+
+func queueSelector(metric, queue string) string {
+	if queue == "" {
+		return metric
+	}
+	return metric + `{name="` + promkey.EscapeLabelValue(queue) + `"}`
+}
+
+func queueScope(queue string) string {
+	if queue == "" {
+		return "all queues"
+	}
+	return `queue name="` + queue + `"`
+}
+
+// ---------------------------
+// rest-client (client-go)
+// ---------------------------
+
+func RestClientRequestsDelta() spec.SLISpec {
+	return spec.SLISpec{
+		ID:          "rest_client_requests_total_delta",
+		Title:       "rest client requests total delta",
+		Unit:        "count",
+		Kind:        "delta_counter",
+		Description: "Delta of rest_client_requests_total during the test window (all codes/methods).",
+		Inputs: []spec.MetricRef{
+			spec.PromSelector("rest_client_requests_total", promkey.AggSum),
+		},
+		Compute: spec.ComputeSpec{Mode: spec.ComputeDelta},
+	}
+}
+
+func RestClient429Delta() spec.SLISpec {
+	return spec.SLISpec{
+		ID:          "rest_client_429_delta",
+		Title:       "rest client 429 delta",
+		Unit:        "count",
+		Kind:        "delta_counter",
+		Description: `Delta of rest_client_requests_total{code="429"}. Indicates API server throttling.`,
+		Inputs: []spec.MetricRef{
+			spec.PromSelector(`rest_client_requests_total{code="429"}`, promkey.AggSum),
+		},
+		Compute: spec.ComputeSpec{Mode: spec.ComputeDelta},
+	}
+}
+
+// RestClient5xxDelta counts every 5xx code; the v3 harness only matched the literal code "5xx".
+func RestClient5xxDelta() spec.SLISpec {
+	return spec.SLISpec{
+		ID:          "rest_client_5xx_delta",
+		Title:       "rest client 5xx delta",
+		Unit:        "count",
+		Kind:        "delta_counter",
+		Description: `Delta of rest_client_requests_total{code=~"5.."} (also matches client-go versions reporting "5xx").`,
+		Inputs: []spec.MetricRef{
+			spec.PromSelector(`rest_client_requests_total{code=~"5..|5xx"}`, promkey.AggSum),
+		},
+		Compute: spec.ComputeSpec{Mode: spec.ComputeDelta},
+	}
+}
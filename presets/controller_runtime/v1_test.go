@@ -0,0 +1,73 @@
+package controller_runtime
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yeongki/my-operator/pkg/slo/spec"
+)
+
+func TestV1KeepsBaselineSpecs(t *testing.T) {
+	// the spec IDs of the v3 harness, in order; new SLIs are opt-in
+	want := []string{
+		"reconcile_total_delta",
+		"reconcile_success_delta",
+		"reconcile_error_delta",
+		"workqueue_adds_total_delta",
+		"workqueue_retries_total_delta",
+		"workqueue_depth_end",
+		"rest_client_requests_total_delta",
+		"rest_client_429_delta",
+		"rest_client_5xx_delta",
+	}
+	var got []string
+	for _, s := range V1() {
+		got = append(got, s.ID)
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+// TestPresetSpecsMeasure pins what every preset spec reads and how: a change here changes
+// the results of the preset and must be called out.
+func TestPresetSpecsMeasure(t *testing.T) {
+	tests := []struct {
+		spec   spec.SLISpec
+		inputs []string
+		mode   spec.ComputeMode
+	}{
+		{ReconcileTotalDelta(), []string{"controller_runtime_reconcile_total"}, spec.ComputeDelta},
+		{ReconcileSuccessDelta(), []string{`controller_runtime_reconcile_total{result="success"}`}, spec.ComputeDelta},
+		{ReconcileErrorDelta(), []string{`controller_runtime_reconcile_total{result="error"}`}, spec.ComputeDelta},
+		{ReconcileErrorRatio(), []string{
+			`controller_runtime_reconcile_total{result="error"}`, "controller_runtime_reconcile_total",
+		}, spec.ComputeRatio},
+		{WorkqueueAddsDelta(""), []string{"workqueue_adds_total"}, spec.ComputeDelta},
+		{WorkqueueAddsDelta("q"), []string{`workqueue_adds_total{name="q"}`}, spec.ComputeDelta},
+		{WorkqueueRetriesDelta(""), []string{"workqueue_retries_total"}, spec.ComputeDelta},
+		{WorkqueueRetriesDelta("q"), []string{`workqueue_retries_total{name="q"}`}, spec.ComputeDelta},
+		// v3: single (start snapshot)
+		{WorkqueueDepthEnd(""), []string{"workqueue_depth"}, spec.ComputeEnd},
+		{WorkqueueDepthEnd("q"), []string{`workqueue_depth{name="q"}`}, spec.ComputeEnd},
+		{WorkqueueDepthMax(""), []string{"workqueue_depth"}, spec.ComputeMax},
+		{RestClientRequestsDelta(), []string{"rest_client_requests_total"}, spec.ComputeDelta},
+		{RestClient429Delta(), []string{`rest_client_requests_total{code="429"}`}, spec.ComputeDelta},
+		// v3: code="5xx" only
+		{RestClient5xxDelta(), []string{`rest_client_requests_total{code=~"5..|5xx"}`}, spec.ComputeDelta},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec.ID, func(t *testing.T) {
+			var inputs []string
+			for _, in := range tt.spec.Inputs {
+				inputs = append(inputs, in.String())
+			}
+			if strings.Join(inputs, " ") != strings.Join(tt.inputs, " ") {
+				t.Fatalf("expected inputs %v, got %v", tt.inputs, inputs)
+			}
+			if tt.spec.Compute.Mode != tt.mode {
+				t.Fatalf("expected mode %s, got %s", tt.mode, tt.spec.Compute.Mode)
+			}
+		})
+	}
+}
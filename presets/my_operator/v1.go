@@ -1,60 +1,62 @@
 package my_operator
 
-// import (
-// 	"github.com/yeongki/my-operator/pkg/slo/spec"
-// 	"github.com/yeongki/my-operator/presets/controller_runtime"
-// )
-
-// func RegisterV1(reg *spec.Registry) {
-// 	// baseline
-// 	controller_runtime.RegisterV1(reg)
-
-// 	// override queue labels (example)
-// 	reg.MustRegister(WorkqueueAddsDeltaMyOperator())
-// 	reg.MustRegister(WorkqueueRetriesDeltaMyOperator())
-
-// 	// operator specific SLIs (examples)
-// 	reg.MustRegister(CRCreatedDelta())
-// }
-
-// func WorkqueueAddsDeltaMyOperator() spec.SLISpec {
-// 	return spec.SLISpec{
-// 		ID:          "my_operator.workqueue_adds_delta",
-// 		Title:       "Workqueue adds delta (my-operator)",
-// 		Unit:        "count",
-// 		Kind:        "delta_counter",
-// 		Description: "Override queue name for my-operator controller.",
-// 		Inputs: []spec.MetricRef{
-// 			spec.PromKey(`workqueue_adds_total{name="my-operator-controller"}`),
-// 		},
-// 		Compute: spec.ComputeSpec{Mode: spec.ComputeDelta},
-// 	}
-// }
-
-// func WorkqueueRetriesDeltaMyOperator() spec.SLISpec {
-// 	return spec.SLISpec{
-// 		ID:    "my_operator.workqueue_retries_delta",
-// 		Title: "Workqueue retries delta (my-operator)",
-// 		Unit:  "count",
-// 		Kind:  "delta_counter",
-// 		Inputs: []spec.MetricRef{
-// 			spec.PromKey(`workqueue_retries_total{name="my-operator-controller"}`),
-// 		},
-// 		Compute: spec.ComputeSpec{Mode: spec.ComputeDelta},
-// 	}
-// }
-
-// // Example: Replace metric name/labels with your real operator metric.
-// func CRCreatedDelta() spec.SLISpec {
-// 	return spec.SLISpec{
-// 		ID:          "my_operator.cr_created_delta",
-// 		Title:       "CR created delta",
-// 		Unit:        "count",
-// 		Kind:        "delta_counter",
-// 		Description: "How many CRs created during the window (churn signal).",
-// 		Inputs: []spec.MetricRef{
-// 			spec.PromKey(`my_operator_cr_created_total{kind="SloJob"}`),
-// 		},
-// 		Compute: spec.ComputeSpec{Mode: spec.ComputeDelta},
-// 	}
-// }
+import (
+	"github.com/yeongki/my-operator/pkg/slo/common/promkey"
+	"github.com/yeongki/my-operator/pkg/slo/spec"
+	"github.com/yeongki/my-operator/presets/controller_runtime"
+)
+
+// QueueName is the workqueue name of the JobOperator controller (see SetupWithManager: Named("joboperator")).
+const QueueName = "joboperator"
+
+// RegisterV1 registers the controller-runtime baseline, pins the workqueue specs to
+// the JobOperator queue (same IDs, so they replace the baseline ones) and adds
+// operator specific SLIs from internal/controller/metrics.go.
+func RegisterV1(reg *spec.Registry) {
+	// baseline
+	controller_runtime.RegisterV1(reg)
+
+	// override queue labels
+	reg.MustOverride(controller_runtime.WorkqueueAddsDelta(QueueName))
+	reg.MustOverride(controller_runtime.WorkqueueRetriesDelta(QueueName))
+	reg.MustOverride(controller_runtime.WorkqueueDepthEnd(QueueName))
+
+	// operator specific SLIs
+	reg.MustRegister(ReconcileErrorsDelta())
+	reg.MustRegister(ReconcileLatency())
+}
+
+// V1 returns the operator preset in registration order.
+func V1() []spec.SLISpec {
+	reg := spec.NewRegistry()
+	RegisterV1(reg)
+	return reg.List()
+}
+
+func ReconcileErrorsDelta() spec.SLISpec {
+	return spec.SLISpec{
+		ID:          "joboperator_reconcile_errors_delta",
+		Title:       "JobOperator reconcile errors delta",
+		Unit:        "count",
+		Kind:        "delta_counter",
+		Description: "Delta of joboperator_reconcile_errors_total during the test window (all error types).",
+		Inputs: []spec.MetricRef{
+			spec.PromSelector("joboperator_reconcile_errors_total", promkey.AggSum),
+		},
+		Compute: spec.ComputeSpec{Mode: spec.ComputeDelta},
+	}
+}
+
+func ReconcileLatency() spec.SLISpec {
+	return spec.SLISpec{
+		ID:          "joboperator_reconcile_latency",
+		Title:       "JobOperator reconcile latency",
+		Unit:        "seconds",
+		Kind:        "histogram",
+		Description: "p50/p90/p99 of joboperator_reconcile_duration_seconds observed during the test window.",
+		Inputs: []spec.MetricRef{
+			spec.PromSelector("joboperator_reconcile_duration_seconds", promkey.AggSum),
+		},
+		Compute: spec.ComputeSpec{Mode: spec.ComputeHistogramQuantile},
+	}
+}
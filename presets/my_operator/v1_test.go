@@ -0,0 +1,34 @@
+package my_operator
+
+import (
+	"testing"
+
+	"github.com/yeongki/my-operator/pkg/slo/spec"
+	"github.com/yeongki/my-operator/presets/controller_runtime"
+)
+
+func TestRegisterV1OverridesBaselineQueue(t *testing.T) {
+	reg := spec.NewRegistry()
+	RegisterV1(reg)
+
+	if err := reg.Validate(); err != nil {
+		t.Fatalf("expected valid preset, got %v", err)
+	}
+
+	specs := reg.List()
+	if len(specs) != len(controller_runtime.V1())+2 {
+		t.Fatalf("expected baseline + 2 operator specs, got %d", len(specs))
+	}
+
+	adds, ok := reg.Get("workqueue_adds_total_delta")
+	if !ok {
+		t.Fatalf("expected workqueue_adds_total_delta to be registered")
+	}
+	if adds.Inputs[0].Selector != `workqueue_adds_total{name="joboperator"}` {
+		t.Fatalf("expected queue override, got %q", adds.Inputs[0].Selector)
+	}
+	// override keeps the baseline position
+	if specs[3].ID != "workqueue_adds_total_delta" {
+		t.Fatalf("expected override to keep its position, got %q", specs[3].ID)
+	}
+}
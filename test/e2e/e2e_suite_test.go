@@ -15,6 +15,8 @@ import (
 	"github.com/yeongki/my-operator/pkg/kubeutil"
 	"github.com/yeongki/my-operator/pkg/slo"
 	"github.com/yeongki/my-operator/test/e2e/e2eutil"
+	"github.com/yeongki/my-operator/test/e2e/harness"
+	e2eenv "github.com/yeongki/my-operator/test/e2e/internal/env"
 )
 
 var (
@@ -41,7 +43,16 @@ func TestE2E(t *testing.T) {
 	RunSpecs(t, "e2e suite")
 }
 
-var _ = BeforeSuite(func() {
+// SynchronizedBeforeSuite sets the cluster up once (process 1 under ginkgo -p) and shares its
+// run id with every process, so all per-test SLI summaries of the run carry the same id.
+var _ = SynchronizedBeforeSuite(func() []byte {
+	setUpSuite()
+	return []byte(e2eenv.ResolveRunID())
+}, func(runID []byte) {
+	Expect(e2eenv.ExportRunID(string(runID))).To(Succeed())
+})
+
+func setUpSuite() {
 	// A reasonable default guard for setup steps.
 	// Individual kubectl commands also have their own timeouts (e.g. kubectl wait --timeout).
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
@@ -77,9 +88,21 @@ var _ = BeforeSuite(func() {
 	By("installing cert-manager")
 	Expect(kubeutil.InstallCertManager(ctx, logger, runner)).
 		To(Succeed(), "Failed to install cert-manager")
-})
+}
+
+// SynchronizedAfterSuite tears down on process 1 once every process has finished, so the
+// run report sees the summaries of all of them.
+var _ = SynchronizedAfterSuite(func() {}, func() {
+	// Merge the per-test SLI summaries of this run (best-effort: measurement failure is not test failure).
+	if opts := e2eenv.LoadOptions(); opts.Enabled {
+		By("writing the SLI run report")
+		if path, err := harness.WriteRunReport(opts.ArtifactsDir, opts.RunID); err != nil {
+			warnf("failed to write SLI run report: %v", err)
+		} else if path != "" {
+			logger.Logf("SLI run report: %s", path)
+		}
+	}
 
-var _ = AfterSuite(func() {
 	if skipCertManagerInstall || isCertManagerAlreadyInstalled {
 		return
 	}
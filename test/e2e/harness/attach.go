@@ -107,7 +107,7 @@ func newSession(hdeps HarnessDeps, fdeps FetchDeps, specs []spec.SLISpec, fns Cu
 	outPath := ""
 	if strings.TrimSpace(hdeps.ArtifactsDir) != "" {
 		filename := fmt.Sprintf(
-			"sli-summary.v3.%s.%s.json",
+			"sli-summary.v4.%s.%s.json",
 			SanitizeFilename(hdeps.RunID),
 			SanitizeFilename(hdeps.TestCase),
 		)
@@ -192,7 +192,7 @@ func (f curlMetricsFetcher) Fetch(ctx context.Context, at time.Time) (fetch.Samp
 		return fetch.Sample{}, err
 	}
 
-	values, err := parsePrometheusText(raw)
+	values, err := promtext.ParseTextToMap(strings.NewReader(raw))
 	if err != nil {
 		return fetch.Sample{}, err
 	}
@@ -202,21 +202,3 @@ func (f curlMetricsFetcher) Fetch(ctx context.Context, at time.Time) (fetch.Samp
 		Values: values,
 	}, nil
 }
-
-func parsePrometheusText(raw string) (map[string]float64, error) {
-	base, err := promtext.ParseTextToMap(strings.NewReader(raw))
-	if err != nil {
-		return nil, err
-	}
-
-	// v3 convenience: also aggregate per-metric-name (strip label set).
-	out := map[string]float64{}
-	for key, val := range base {
-		out[key] = val
-		if idx := strings.Index(key, "{"); idx > 0 {
-			name := key[:idx]
-			out[name] = out[name] + val
-		}
-	}
-	return out, nil
-}
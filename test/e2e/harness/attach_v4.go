@@ -7,24 +7,57 @@ import (
 	"time"
 
 	"github.com/onsi/ginkgo/v2"
+
+	"github.com/yeongki/my-operator/pkg/slo/engine"
+	"github.com/yeongki/my-operator/pkg/slo/fetch"
 )
 
-// AttachV4Config defines the minimal v4 inputs for InsideSnapshot.
+// AttachV4Config defines the minimal v4 inputs (InsideSnapshot by default).
 type AttachV4Config struct {
 	Namespace          string
 	MetricsServiceName string
 	TestCase           string
 	Suite              string
-	RunID              string
+	RunID              string // env.Options.RunID, resolved once per suite
 	ServiceAccountName string
 	Token              string
 
 	ArtifactsDir string
 	Tags         map[string]string
+
+	// SpecFile points to a YAML/JSON spec document; empty uses the default presets.
+	SpecFile string
+
+	// SampleInterval enables background sampling during each test (0 = start/end only).
+	SampleInterval time.Duration
+
+	// Method defaults to InsideSnapshot. OutsideSnapshot scrapes MetricsURL directly,
+	// or port-forwards to the metrics service when MetricsURL is empty.
+	Method             engine.MeasurementMethod
+	MetricsURL         string
+	CAFile             string
+	InsecureSkipVerify bool
+	OpenMetrics        bool
+	Protobuf           bool
+	// AllReplicas scrapes every controller-manager pod through its own port-forward.
+	AllReplicas bool
+
+	// PrometheusURL computes SLIs from an existing Prometheus instead of scraping the operator.
+	PrometheusURL   string
+	PrometheusToken string
+
+	// Retry overrides the retry policy of every scrape (see SessionV4Config.Retry).
+	Retry *fetch.RetryPolicy
+
+	// RecordScrapes stores raw /metrics bodies under ArtifactsDir for offline replay.
+	RecordScrapes bool
+	// Reports adds report sinks next to the JSON summary ("junit", "markdown", "html").
+	Reports []string
 }
 
 // AttachV4 provides a v4 Ginkgo entrypoint that does not require CurlPodFns.
 // It creates, starts, and ends a v4 session around each test case.
+// Call Close on the returned session after the suite to stop a port-forward.
 func AttachV4(cfg AttachV4Config) (*SessionV4, error) {
 	if cfg.Namespace == "" {
 		return nil, errors.New("v4: Namespace is required")
@@ -47,7 +80,20 @@ func AttachV4(cfg AttachV4Config) (*SessionV4, error) {
 		Token:              cfg.Token,
 		ArtifactsDir:       cfg.ArtifactsDir,
 		Tags:               cfg.Tags,
-		Now:                time.Now,
+		SampleInterval:     cfg.SampleInterval,
+		SpecFile:           cfg.SpecFile,
+		Method:             cfg.Method,
+		MetricsURL:         cfg.MetricsURL,
+		CAFile:             cfg.CAFile,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		AllReplicas:        cfg.AllReplicas,
+		OpenMetrics:        cfg.OpenMetrics,
+		Protobuf:           cfg.Protobuf,
+		RecordScrapes:      cfg.RecordScrapes,
+		Reports:            cfg.Reports,
+		Retry:              cfg.Retry,
+		PrometheusURL:      cfg.PrometheusURL,
+		PrometheusToken:    cfg.PrometheusToken,
 	})
 
 	ginkgo.BeforeEach(func() {
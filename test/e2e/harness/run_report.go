@@ -0,0 +1,38 @@
+package harness
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/yeongki/my-operator/pkg/slo/summary"
+)
+
+// WriteRunReport merges the summaries of runID in artifactsDir into one run report, written as
+// sli-run-report.<runID>.json and .md next to them. Call it once at suite end (AfterSuite).
+//
+// It returns the JSON path, or "" when artifactsDir is empty (artifacts disabled) or does not
+// exist (no test wrote a summary). An empty runID is an error: the artifacts directory may
+// hold the summaries of earlier runs, and they must not end up in this report.
+func WriteRunReport(artifactsDir, runID string) (string, error) {
+	if strings.TrimSpace(artifactsDir) == "" {
+		return "", nil
+	}
+	if strings.TrimSpace(runID) == "" {
+		return "", errors.New("run report: run id is required")
+	}
+	rep, err := summary.AggregateDir(artifactsDir, runID)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("aggregate run %q: %w", runID, err)
+	}
+	path := filepath.Join(artifactsDir, fmt.Sprintf("sli-run-report.%s.json", SanitizeFilename(runID)))
+	if err := summary.WriteRunReport(path, rep); err != nil {
+		return "", err
+	}
+	return path, nil
+}
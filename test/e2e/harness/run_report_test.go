@@ -0,0 +1,55 @@
+package harness
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteRunReport(t *testing.T) {
+	if path, err := WriteRunReport("", "run-1"); err != nil || path != "" {
+		t.Fatalf("expected no report without artifacts dir, got %q, %v", path, err)
+	}
+	dir := t.TempDir()
+	if _, err := WriteRunReport(dir, " "); err == nil {
+		t.Fatal("expected an empty run id to be rejected")
+	}
+	if path, err := WriteRunReport(filepath.Join(dir, "missing"), "run-1"); err != nil || path != "" {
+		t.Fatalf("expected no report for a missing artifacts dir, got %q, %v", path, err)
+	}
+
+	for name, runID := range map[string]string{
+		"sli-summary.v4.run-1.a.json": "run-1",
+		"sli-summary.v4.run-1.b.json": "run-1",
+		"sli-summary.v4.run-2.a.json": "run-2",
+	} {
+		body := `{"schemaVersion":"slo.v4","config":{"runId":"` + runID + `"},` +
+			`"results":[{"id":"reconcile_p99","status":"pass","value":0.5}]}`
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path, err := WriteRunReport(dir, "run/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(dir, "sli-run-report.run_1.json") {
+		t.Fatalf("unexpected report path %q", path)
+	}
+	path, err = WriteRunReport(dir, "run-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(raw), `"summaries": 2`) || !strings.Contains(string(raw), `"pass": 2`) {
+		t.Fatalf("expected the two run-1 summaries merged, got\n%s", raw)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sli-run-report.run-1.md")); err != nil {
+		t.Fatalf("expected Markdown report: %v", err)
+	}
+}
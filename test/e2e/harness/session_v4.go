@@ -2,19 +2,23 @@ package harness
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
 	"github.com/yeongki/my-operator/pkg/slo/engine"
 	"github.com/yeongki/my-operator/pkg/slo/fetch"
-	"github.com/yeongki/my-operator/pkg/slo/fetch/promtext"
 	"github.com/yeongki/my-operator/pkg/slo/spec"
 	"github.com/yeongki/my-operator/pkg/slo/summary"
 	"github.com/yeongki/my-operator/pkg/slo/tags"
 	"github.com/yeongki/my-operator/test/e2e/curlmetrics"
+	"github.com/yeongki/my-operator/test/e2e/portforward"
 )
 
 // SessionV4Config contains v4 session inputs and defaults.
@@ -28,10 +32,62 @@ type SessionV4Config struct {
 	Token              string
 	ArtifactsDir       string
 	Tags               map[string]string
-	Now                func() time.Time
 
 	Specs   []spec.SLISpec
 	Fetcher fetch.MetricsFetcher
+
+	// SpecFile points to a YAML/JSON spec document (see spec.LoadFile).
+	// It is used when Specs is nil.
+	SpecFile string
+
+	// SampleInterval enables background sampling between Start and End (0 = start/end only).
+	SampleInterval time.Duration
+
+	// Method selects how snapshots are taken when Fetcher is nil.
+	// InsideSnapshot (default) runs a curl pod per scrape; OutsideSnapshot scrapes
+	// MetricsURL directly from the test process (see fetch.HTTPFetcher), using Token.
+	// Without MetricsURL, OutsideSnapshot port-forwards to a pod behind MetricsServiceName.
+	Method             engine.MeasurementMethod
+	MetricsURL         string
+	CAFile             string
+	InsecureSkipVerify bool
+	// OpenMetrics requests application/openmetrics-text from the metrics endpoint (OutsideSnapshot).
+	OpenMetrics bool
+	// Protobuf requests the delimited protobuf format, cheaper to parse for large expositions.
+	Protobuf bool
+
+	// RestConfig is used by the port-forward (default: kubeconfig of the test process).
+	// PortForwardDialer replaces the SPDY dialer (tests).
+	RestConfig        *rest.Config
+	PortForwardDialer portforward.Dialer
+
+	// AllReplicas port-forwards to every ready pod behind MetricsServiceName instead of one,
+	// and tags series with an "instance" label (OutsideSnapshot without MetricsURL only).
+	// Use it with leader election and several replicas: selectors then sum across replicas,
+	// and inputs with Leader: true only read the leader (see fetch.MultiTargetFetcher).
+	AllReplicas bool
+
+	// PrometheusURL computes snapshots from an existing Prometheus (/api/v1/query) instead of
+	// scraping the operator; only the series read by Specs are queried. With SampleInterval,
+	// window samples come from one range query at End instead of a background sampler.
+	PrometheusURL   string
+	PrometheusToken string
+
+	// Retry is applied to every snapshot and sample. It is the only retry layer: the HTTP
+	// fetchers of OutsideSnapshot do not retry on their own. nil means fetch.DefaultRetryPolicy,
+	// except for the curl pod of InsideSnapshot, which is not retried unless Retry is set:
+	// one try there runs a pod and can take minutes. Attempts: 1 disables retries.
+	// Retried scrapes are listed in the summary warnings.
+	Retry *fetch.RetryPolicy
+
+	// RecordScrapes stores every raw /metrics body under
+	// <ArtifactsDir>/scrapes/<runID>/<testCase>-<start> for offline replay (fetch.ReplayFetcher).
+	// Only fetchers that expose raw bodies (fetch.Scraper) can be recorded.
+	RecordScrapes bool
+
+	// Reports adds report sinks next to the JSON summary: "junit" (.junit.xml), "markdown" (.md)
+	// and "html" (.html). A failing sink only adds a summary warning (see summary.MultiWriter).
+	Reports []string
 }
 
 // SessionV4 holds v4 runtime state.
@@ -55,19 +111,17 @@ type SessionV4 struct {
 	fetcher fetch.MetricsFetcher
 	writer  summary.Writer
 	started time.Time
+	sampler *engine.Sampler
+	// samplerFetcher is the fetcher chain of the sampler; its warnings are collected at End.
+	samplerFetcher fetch.MetricsFetcher
+	// recordDir is the scrape recording directory of the current Start/End window.
+	recordDir string
 }
 
 // NewSessionV4 builds a session with defaults applied.
 func NewSessionV4(cfg SessionV4Config) *SessionV4 {
-	now := cfg.Now
-	if now == nil {
-		now = time.Now
-	}
-
-	runID := cfg.RunID
-	if strings.TrimSpace(runID) == "" {
-		runID = fmt.Sprintf("local-%d", now().Unix())
-	}
+	// the suite resolves the run id once for all processes (env.ResolveRunID): no fallback here
+	runID := strings.TrimSpace(cfg.RunID)
 
 	autoTags := tags.AutoTagsV4(tags.AutoTagsV4Input{
 		Suite:     cfg.Suite,
@@ -78,6 +132,29 @@ func NewSessionV4(cfg SessionV4Config) *SessionV4 {
 
 	mergedTags := tags.MergeTagsV4(cfg.Tags, autoTags)
 
+	specs, warnings := resolveSpecsV4(cfg)
+	writer, writerWarnings := summaryWriterV4(cfg.Reports)
+	warnings = append(warnings, writerWarnings...)
+
+	fetcher := cfg.Fetcher
+	if fetcher == nil {
+		var err error
+		switch {
+		case cfg.PrometheusURL != "":
+			fetcher, err = fetch.NewPromAPIFetcher(fetch.PromAPIConfig{
+				URL:         cfg.PrometheusURL,
+				Queries:     spec.QuerySelectors(specs),
+				BearerToken: cfg.PrometheusToken,
+			})
+		case cfg.Method == engine.OutsideSnapshot:
+			fetcher, err = newOutsideFetcherV4(cfg)
+		}
+		if err != nil {
+			// keep the session usable: every scrape reports the setup problem as a fetch warning
+			fetcher = errFetcher{err: fetch.Permanent(err)}
+		}
+	}
+
 	return &SessionV4{
 		Config:             cfg,
 		MetricsPort:        8443,
@@ -88,25 +165,52 @@ func NewSessionV4(cfg SessionV4Config) *SessionV4 {
 		LogsTimeout:        2 * time.Minute,
 		RunID:              runID,
 		Tags:               mergedTags,
-		specs:              defaultSpecsV4(cfg.Specs),
-		fetcher:            cfg.Fetcher,
-		writer:             summary.NewJSONFileWriter(),
+		Warnings:           warnings,
+		specs:              specs,
+		fetcher:            fetcher,
+		writer:             writer,
 	}
 }
 
+// summaryWriterV4 writes the requested reports, then the JSON summary, so the JSON lists
+// the failures of every other sink. Unknown report names become warnings.
+func summaryWriterV4(reports []string) (summary.Writer, []string) {
+	var (
+		sinks    []summary.Sink
+		warnings []string
+	)
+	for _, r := range reports {
+		switch strings.ToLower(strings.TrimSpace(r)) {
+		case "junit":
+			sinks = append(sinks, summary.JUnitSink())
+		case "markdown", "md":
+			sinks = append(sinks, summary.MarkdownSink())
+		case "html":
+			sinks = append(sinks, summary.HTMLSink())
+		case "json":
+			// always written
+		default:
+			warnings = append(warnings, fmt.Sprintf("v4: unknown report %q (want junit|markdown|html)", r))
+		}
+	}
+	return summary.NewMultiWriter(append(sinks, summary.JSONSink())...), warnings
+}
+
 // ShouldWriteArtifacts reports whether v4 should write summary output.
 func (s *SessionV4) ShouldWriteArtifacts() bool {
 	return s.Config.ArtifactsDir != ""
 }
 
-// NextSummaryPath returns a unique summary path by appending -<n> on collisions.
+// NextSummaryPath returns a unique summary path by inserting -<n> before the extension on
+// collisions (x.json, x-1.json, ...), so the report sinks derived from it stay unique too.
 func (s *SessionV4) NextSummaryPath(filename string) (string, error) {
 	if s.Config.ArtifactsDir == "" {
 		return "", nil
 	}
 
-	base := filepath.Join(s.Config.ArtifactsDir, filename)
-	path := base
+	path := filepath.Join(s.Config.ArtifactsDir, filename)
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
 	for i := 1; ; i++ {
 		_, err := os.Stat(path)
 		if err != nil {
@@ -115,11 +219,12 @@ func (s *SessionV4) NextSummaryPath(filename string) (string, error) {
 			}
 			return "", err
 		}
-		path = fmt.Sprintf("%s-%d", base, i)
+		path = fmt.Sprintf("%s-%d%s", base, i, ext)
 	}
 }
 
 // AddWarning records a warning message for BestEffort mode.
+// The warning is session-wide: it is included in the summary of every later End.
 func (s *SessionV4) AddWarning(message string) {
 	if message == "" {
 		return
@@ -128,8 +233,24 @@ func (s *SessionV4) AddWarning(message string) {
 }
 
 // Start begins v4 measurement.
+// With SampleInterval > 0 it also starts the background sampler.
 func (s *SessionV4) Start() {
 	s.started = time.Now()
+
+	s.recordDir = ""
+	if s.Config.RecordScrapes && s.ShouldWriteArtifacts() {
+		s.recordDir = filepath.Join(
+			s.Config.ArtifactsDir, "scrapes", SanitizeFilename(s.RunID),
+			SanitizeFilename(s.Config.TestCase)+"-"+s.started.UTC().Format("20060102T150405"),
+		)
+	}
+
+	s.sampler, s.samplerFetcher = nil, nil
+	if _, ok := s.fetcher.(fetch.RangeFetcher); s.Config.SampleInterval > 0 && !ok {
+		s.samplerFetcher = s.metricsFetcher()
+		s.sampler = engine.NewSampler(s.samplerFetcher, s.Config.SampleInterval, nil)
+		s.sampler.Start(context.Background())
+	}
 }
 
 // End completes v4 measurement.
@@ -139,16 +260,38 @@ func (s *SessionV4) End(ctx context.Context) (*summary.Summary, error) {
 	}
 	finished := time.Now()
 
-	fetcher := s.fetcher
-	if fetcher == nil {
-		fetcher = newCurlPodFetcherV4(s)
+	// s.Warnings holds the setup warnings shared by every window; the warnings of this
+	// window stay local so they do not leak into the summaries of later tests.
+	warnings := append([]string(nil), s.Warnings...)
+
+	var samples []fetch.Sample
+	if s.sampler != nil {
+		samples = s.sampler.Stop()
+		if n := s.sampler.Failures(); n > 0 {
+			warnings = append(warnings, fmt.Sprintf("sampler: %d scrape(s) failed", n))
+		}
+		if r, ok := s.samplerFetcher.(fetch.WarningReporter); ok {
+			for _, w := range r.TakeWarnings() {
+				warnings = append(warnings, "sampler: "+w)
+			}
+		}
+		s.sampler, s.samplerFetcher = nil, nil
+	}
+	if rf, ok := s.fetcher.(fetch.RangeFetcher); ok && s.Config.SampleInterval > 0 {
+		// a failed range query keeps whatever the sampler collected
+		ranged, err := rf.FetchRange(ctx, s.started, finished, s.Config.SampleInterval)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("range fetch failed: %v", err))
+		} else {
+			samples = ranged
+		}
 	}
 
-	eng := engine.New(fetcher, s.writer, nil)
+	eng := engine.New(s.metricsFetcher(), s.writer, nil)
 	outPath := ""
 	if s.ShouldWriteArtifacts() {
 		filename := fmt.Sprintf(
-			"sli-summary.v3.%s.%s.json",
+			"sli-summary.v4.%s.%s.json",
 			SanitizeFilename(s.RunID),
 			SanitizeFilename(s.Config.TestCase),
 		)
@@ -160,7 +303,7 @@ func (s *SessionV4) End(ctx context.Context) (*summary.Summary, error) {
 	}
 
 	return engine.ExecuteV4(ctx, eng, engine.ExecuteRequestV4{
-		Method: engine.InsideSnapshot,
+		Method: s.method(),
 		Config: engine.RunConfig{
 			RunID:      s.RunID,
 			StartedAt:  s.started,
@@ -168,11 +311,117 @@ func (s *SessionV4) End(ctx context.Context) (*summary.Summary, error) {
 			Format:     "v4",
 			Tags:       s.Tags,
 		},
-		Specs:   s.specs,
-		OutPath: outPath,
+		Specs:    s.specs,
+		OutPath:  outPath,
+		Samples:  samples,
+		Warnings: warnings,
 	})
 }
 
+func (s *SessionV4) method() engine.MeasurementMethod {
+	switch {
+	case s.Config.Method != "":
+		return s.Config.Method
+	case s.Config.PrometheusURL != "":
+		// queried from the test process, not from inside the cluster
+		return engine.OutsideSnapshot
+	default:
+		return engine.InsideSnapshot
+	}
+}
+
+// metricsFetcher returns the configured fetcher, or the default curl pod fetcher,
+// wrapped in a recorder when RecordScrapes is on and in the retry policy (see Config.Retry).
+func (s *SessionV4) metricsFetcher() fetch.MetricsFetcher {
+	f := s.fetcher
+	policy := fetch.DefaultRetryPolicy
+	if f == nil {
+		f = newCurlPodFetcherV4(s)
+		policy = fetch.RetryPolicy{Attempts: 1}
+	}
+	if s.recordDir != "" {
+		if scraper, ok := f.(fetch.Scraper); ok {
+			f = fetch.NewRecordingFetcher(scraper, s.recordDir)
+		}
+	}
+	if s.Config.Retry != nil {
+		policy = *s.Config.Retry
+	}
+	if policy.Attempts == 1 {
+		return f
+	}
+	return fetch.NewRetryFetcher(f, policy)
+}
+
+// newOutsideFetcherV4 builds the fetcher of OutsideSnapshot: direct HTTP to MetricsURL,
+// or a port-forward to the metrics service (one forward per session).
+func newOutsideFetcherV4(cfg SessionV4Config) (fetch.MetricsFetcher, error) {
+	httpCfg := fetch.HTTPConfig{
+		URL:                cfg.MetricsURL,
+		BearerToken:        cfg.Token,
+		CAFile:             cfg.CAFile,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		OpenMetrics:        cfg.OpenMetrics,
+		Protobuf:           cfg.Protobuf,
+		// retries belong to the RetryFetcher of metricsFetcher, not to each HTTP scrape
+		Retries: 0,
+	}
+	if strings.TrimSpace(cfg.MetricsURL) != "" {
+		if cfg.AllReplicas {
+			return nil, errors.New("v4: AllReplicas needs the port-forward (leave MetricsURL empty)")
+		}
+		return fetch.NewHTTPFetcher(httpCfg)
+	}
+
+	if cfg.CAFile != "" {
+		// the serving cert is issued for the service, not for 127.0.0.1
+		httpCfg.ServerName = fmt.Sprintf("%s.%s.svc", cfg.MetricsServiceName, cfg.Namespace)
+	} else {
+		// same as the curl pod path (curl -k): the default metrics cert is self-signed
+		httpCfg.InsecureSkipVerify = true
+	}
+
+	if cfg.PortForwardDialer != nil && !cfg.AllReplicas {
+		return &portforward.Fetcher{Dialer: cfg.PortForwardDialer, HTTP: httpCfg}, nil
+	}
+	if cfg.Namespace == "" || cfg.MetricsServiceName == "" {
+		return nil, fmt.Errorf("v4: %s needs MetricsURL or Namespace/MetricsServiceName", engine.OutsideSnapshot)
+	}
+	restCfg := cfg.RestConfig
+	if restCfg == nil {
+		c, err := config.GetConfig()
+		if err != nil {
+			return nil, fmt.Errorf("v4: port-forward: load kubeconfig: %w", err)
+		}
+		restCfg = c
+	}
+	if cfg.AllReplicas {
+		return &fetch.MultiTargetFetcher{Discoverer: &portforward.ServiceTargets{
+			Config:      restCfg,
+			Namespace:   cfg.Namespace,
+			ServiceName: cfg.MetricsServiceName,
+			HTTP:        httpCfg,
+		}}, nil
+	}
+	dialer := &portforward.SPDYDialer{Config: restCfg, Namespace: cfg.Namespace, ServiceName: cfg.MetricsServiceName}
+	return &portforward.Fetcher{Dialer: dialer, HTTP: httpCfg}, nil
+}
+
+// Close releases session resources such as the port-forward of OutsideSnapshot.
+// Call it once after the last test (e.g. in AfterSuite).
+func (s *SessionV4) Close() {
+	if c, ok := s.fetcher.(interface{ Close() }); ok {
+		c.Close()
+	}
+}
+
+// errFetcher fails every scrape with a setup error.
+type errFetcher struct{ err error }
+
+func (f errFetcher) Fetch(context.Context, time.Time) (fetch.Sample, error) {
+	return fetch.Sample{}, f.err
+}
+
 type curlPodFetcherV4 struct {
 	session *SessionV4
 	pod     *curlmetrics.CurlPodV4
@@ -193,40 +442,38 @@ func newCurlPodFetcherV4(session *SessionV4) fetch.MetricsFetcher {
 }
 
 func (f *curlPodFetcherV4) Fetch(ctx context.Context, at time.Time) (fetch.Sample, error) {
-	podCtx, cancel := context.WithTimeout(ctx, f.session.ScrapeTimeout)
-	defer cancel()
-
-	raw, err := f.pod.Run(podCtx, f.session.WaitPodDoneTimeout, f.session.LogsTimeout)
+	raw, _, err := f.Scrape(ctx)
 	if err != nil {
 		return fetch.Sample{}, err
 	}
+	return fetch.ParseSample(raw, at)
+}
+
+// Scrape runs one curl pod and returns the /metrics body from its logs.
+// Pod logs carry no Content-Type, so the format is guessed from the body.
+func (f *curlPodFetcherV4) Scrape(ctx context.Context) ([]byte, string, error) {
+	podCtx, cancel := context.WithTimeout(ctx, f.session.ScrapeTimeout)
+	defer cancel()
 
-	values, err := parsePrometheusTextV4(raw)
+	raw, err := f.pod.Run(podCtx, f.session.WaitPodDoneTimeout, f.session.LogsTimeout)
 	if err != nil {
-		return fetch.Sample{}, err
+		return nil, "", err
 	}
-
-	return fetch.Sample{
-		At:     at,
-		Values: values,
-	}, nil
+	return []byte(raw), "", nil
 }
 
-func parsePrometheusTextV4(raw string) (map[string]float64, error) {
-	base, err := promtext.ParseTextToMap(strings.NewReader(raw))
-	if err != nil {
-		return nil, err
+// resolveSpecsV4 picks Specs, then SpecFile, then the default presets.
+// A broken spec file is a measurement problem, not a test failure:
+// it is reported as a warning and no SLI is evaluated.
+func resolveSpecsV4(cfg SessionV4Config) ([]spec.SLISpec, []string) {
+	if cfg.Specs != nil || strings.TrimSpace(cfg.SpecFile) == "" {
+		return defaultSpecsV4(cfg.Specs), nil
 	}
-
-	out := map[string]float64{}
-	for key, val := range base {
-		out[key] = val
-		if idx := strings.Index(key, "{"); idx > 0 {
-			name := key[:idx]
-			out[name] = out[name] + val
-		}
+	specs, err := spec.LoadFile(cfg.SpecFile)
+	if err != nil {
+		return []spec.SLISpec{}, []string{fmt.Sprintf("load spec file: %v", err)}
 	}
-	return out, nil
+	return specs, nil
 }
 
 func defaultSpecsV4(specs []spec.SLISpec) []spec.SLISpec {
@@ -2,11 +2,20 @@ package harness
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/yeongki/my-operator/pkg/slo/engine"
 	"github.com/yeongki/my-operator/pkg/slo/fetch"
 	"github.com/yeongki/my-operator/pkg/slo/spec"
+	"github.com/yeongki/my-operator/test/e2e/portforward"
 )
 
 type fakeFetcherV4 struct {
@@ -59,8 +68,8 @@ func TestSessionV4BuildsSummary(t *testing.T) {
 	if summary == nil {
 		t.Fatalf("expected summary, got nil")
 	}
-	if summary.SchemaVersion != "slo.v3" {
-		t.Fatalf("expected schemaVersion slo.v3, got %q", summary.SchemaVersion)
+	if summary.SchemaVersion != "slo.v4" {
+		t.Fatalf("expected schemaVersion slo.v4, got %q", summary.SchemaVersion)
 	}
 	if summary.Config.Format != "v4" {
 		t.Fatalf("expected config.format v4, got %q", summary.Config.Format)
@@ -72,3 +81,328 @@ func TestSessionV4BuildsSummary(t *testing.T) {
 		t.Fatalf("expected user run_id tag override, got %q", summary.Config.Tags["run_id"])
 	}
 }
+
+func TestSessionV4OutsideSnapshotScrapesURL(t *testing.T) {
+	var value atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = fmt.Fprintf(w, "requests_total %d\n", value.Add(5))
+	}))
+	defer srv.Close()
+
+	session := NewSessionV4(SessionV4Config{
+		TestCase:   "case",
+		RunID:      "run-1",
+		Token:      "token",
+		Method:     engine.OutsideSnapshot,
+		MetricsURL: srv.URL,
+		Specs: []spec.SLISpec{{
+			ID:      "requests_delta",
+			Inputs:  []spec.MetricRef{spec.PromMetric("requests_total", nil)},
+			Compute: spec.ComputeSpec{Mode: spec.ComputeDelta},
+		}},
+	})
+
+	session.Start()
+	sum, err := session.End(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if sum.Config.Mode.Location != string(engine.RunLocationOutside) {
+		t.Fatalf("expected outside run mode, got %q", sum.Config.Mode.Location)
+	}
+	if len(sum.Results) != 1 || sum.Results[0].Value == nil || *sum.Results[0].Value != 5 {
+		t.Fatalf("expected delta 5, got %+v", sum.Results)
+	}
+}
+
+func TestSessionV4RetriesTransientScrapeFailures(t *testing.T) {
+	var calls, value atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = fmt.Fprintf(w, "requests_total %d\n", value.Add(5))
+	}))
+	defer srv.Close()
+
+	session := NewSessionV4(SessionV4Config{
+		TestCase:   "case",
+		RunID:      "run-1",
+		Method:     engine.OutsideSnapshot,
+		MetricsURL: srv.URL,
+		Retry:      &fetch.RetryPolicy{InitialBackoff: time.Millisecond},
+		Specs: []spec.SLISpec{{
+			ID:      "requests_delta",
+			Inputs:  []spec.MetricRef{spec.PromMetric("requests_total", nil)},
+			Compute: spec.ComputeSpec{Mode: spec.ComputeDelta},
+		}},
+	})
+
+	session.Start()
+	sum, err := session.End(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(sum.Results) != 1 || sum.Results[0].Value == nil || *sum.Results[0].Value != 5 {
+		t.Fatalf("expected delta 5, got %+v", sum.Results)
+	}
+	if len(sum.Warnings) != 1 || !strings.Contains(sum.Warnings[0], "succeeded after 2 attempts") {
+		t.Fatalf("expected retry warning, got %v", sum.Warnings)
+	}
+}
+
+func TestSessionV4OutsideSnapshotPortForwards(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprintln(w, "requests_total 7")
+	}))
+	defer srv.Close()
+
+	dialer := &fakeDialerV4{addr: strings.TrimPrefix(srv.URL, "http://")}
+	session := NewSessionV4(SessionV4Config{
+		TestCase:          "case",
+		Method:            engine.OutsideSnapshot,
+		PortForwardDialer: dialer,
+		Specs: []spec.SLISpec{{
+			ID:      "requests_end",
+			Inputs:  []spec.MetricRef{spec.PromMetric("requests_total", nil)},
+			Compute: spec.ComputeSpec{Mode: spec.ComputeEnd},
+		}},
+	})
+	// the fake forward serves plain HTTP
+	session.fetcher.(*portforward.Fetcher).Scheme = "http"
+	defer session.Close()
+
+	session.Start()
+	sum, err := session.End(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(sum.Warnings) != 0 || sum.Results[0].Value == nil || *sum.Results[0].Value != 7 {
+		t.Fatalf("expected value 7 without warnings, got %+v / %v", sum.Results, sum.Warnings)
+	}
+	if dialer.dials != 1 {
+		t.Fatalf("expected one forward for start and end, got %d", dialer.dials)
+	}
+}
+
+type fakeDialerV4 struct {
+	addr  string
+	dials int
+}
+
+func (d *fakeDialerV4) Dial(context.Context) (portforward.Forward, error) {
+	d.dials++
+	return fakeForwardV4{addr: d.addr, done: make(chan struct{})}, nil
+}
+
+type fakeForwardV4 struct {
+	addr string
+	done chan struct{}
+}
+
+func (f fakeForwardV4) Addr() string          { return f.addr }
+func (f fakeForwardV4) Done() <-chan struct{} { return f.done }
+func (f fakeForwardV4) Close()                {}
+
+// scrapeFetcherV4 exposes raw bodies like the curl pod and HTTP fetchers.
+type scrapeFetcherV4 struct{ n int }
+
+func (f *scrapeFetcherV4) Scrape(context.Context) ([]byte, string, error) {
+	f.n++
+	return []byte(fmt.Sprintf("requests_total %d\n", f.n)), "", nil
+}
+
+func (f *scrapeFetcherV4) Fetch(ctx context.Context, at time.Time) (fetch.Sample, error) {
+	body, _, _ := f.Scrape(ctx)
+	return fetch.ParseSample(body, at)
+}
+
+func TestSessionV4RecordsScrapes(t *testing.T) {
+	dir := t.TempDir()
+	session := NewSessionV4(SessionV4Config{
+		TestCase:      "case",
+		RunID:         "run-1",
+		ArtifactsDir:  dir,
+		Fetcher:       &scrapeFetcherV4{},
+		RecordScrapes: true,
+		Specs:         []spec.SLISpec{},
+	})
+	session.Start()
+	if _, err := session.End(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	replay, err := fetch.NewReplayFetcher(session.recordDir)
+	if err != nil {
+		t.Fatalf("expected recordings, got %v", err)
+	}
+	if n := len(replay.Recordings()); n != 2 {
+		t.Fatalf("expected start and end recordings, got %d", n)
+	}
+}
+
+func TestSessionV4WritesReports(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Minute)
+	session := NewSessionV4(SessionV4Config{
+		TestCase:     "case",
+		RunID:        "run-1",
+		ArtifactsDir: dir,
+		Reports:      []string{"junit", "html", "pdf"},
+		Fetcher: &fakeFetcherV4{samples: []fetch.Sample{
+			{At: start, Values: map[string]float64{"metric": 1}},
+			{At: time.Now(), Values: map[string]float64{"metric": 3}},
+		}},
+		Specs: []spec.SLISpec{{
+			ID:      "metric_delta",
+			Inputs:  []spec.MetricRef{spec.PromMetric("metric", nil)},
+			Compute: spec.ComputeSpec{Mode: spec.ComputeDelta},
+		}},
+	})
+	// a directory in place of the HTML report makes that sink fail
+	base := filepath.Join(dir, "sli-summary.v4.run-1.case")
+	if err := os.Mkdir(base+".html", 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	session.Start()
+	sum, err := session.End(context.Background())
+	if err != nil {
+		t.Fatalf("expected a failing sink not to fail the session, got %v", err)
+	}
+	if _, err := os.Stat(base + ".junit.xml"); err != nil {
+		t.Fatalf("expected JUnit report: %v", err)
+	}
+	written, err := os.ReadFile(base + ".json")
+	if err != nil {
+		t.Fatalf("expected JSON summary: %v", err)
+	}
+	for _, warnings := range []string{strings.Join(sum.Warnings, "\n"), string(written)} {
+		if !strings.Contains(warnings, "unknown report") || !strings.Contains(warnings, "summary sink html:") {
+			t.Fatalf("expected report warnings, got %s", warnings)
+		}
+	}
+}
+
+func TestSessionV4KeepsReportsOfCollidingSummaries(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Minute)
+	session := NewSessionV4(SessionV4Config{
+		TestCase:     "case",
+		RunID:        "run-1",
+		ArtifactsDir: dir,
+		Reports:      []string{"junit", "markdown", "html"},
+		Fetcher: &fakeFetcherV4{samples: []fetch.Sample{
+			{At: start, Values: map[string]float64{"metric": 1}},
+			{At: time.Now(), Values: map[string]float64{"metric": 3}},
+			{At: start, Values: map[string]float64{"metric": 3}},
+			{At: time.Now(), Values: map[string]float64{"metric": 4}},
+		}},
+		Specs: []spec.SLISpec{{
+			ID:      "metric_delta",
+			Inputs:  []spec.MetricRef{spec.PromMetric("metric", nil)},
+			Compute: spec.ComputeSpec{Mode: spec.ComputeDelta},
+		}},
+	})
+	for range 2 {
+		session.Start()
+		if _, err := session.End(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, base := range []string{"sli-summary.v4.run-1.case", "sli-summary.v4.run-1.case-1"} {
+		for _, ext := range []string{".json", ".junit.xml", ".md", ".html"} {
+			if _, err := os.Stat(filepath.Join(dir, base+ext)); err != nil {
+				t.Fatalf("expected %s%s: %v", base, ext, err)
+			}
+		}
+	}
+}
+
+type failingFetcherV4 struct{}
+
+func (failingFetcherV4) Fetch(context.Context, time.Time) (fetch.Sample, error) {
+	return fetch.Sample{}, fmt.Errorf("connection refused")
+}
+
+func TestSessionV4KeepsWindowWarningsPerWindow(t *testing.T) {
+	session := NewSessionV4(SessionV4Config{
+		TestCase:       "case",
+		RunID:          "run-1",
+		Reports:        []string{"pdf"},
+		Fetcher:        failingFetcherV4{},
+		Retry:          &fetch.RetryPolicy{Attempts: 1},
+		SampleInterval: time.Millisecond,
+	})
+
+	for i := 0; i < 2; i++ {
+		session.Start()
+		time.Sleep(20 * time.Millisecond)
+		sum, err := session.End(context.Background())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		var setup, sampler int
+		for _, w := range sum.Warnings {
+			switch {
+			case strings.Contains(w, "unknown report"):
+				setup++
+			case strings.HasPrefix(w, "sampler: "):
+				sampler++
+			}
+		}
+		if setup != 1 || sampler != 1 {
+			t.Fatalf("window %d: expected one setup and one sampler warning, got %v", i, sum.Warnings)
+		}
+	}
+}
+
+type failingRangeFetcherV4 struct{}
+
+func (failingRangeFetcherV4) Fetch(_ context.Context, at time.Time) (fetch.Sample, error) {
+	return fetch.Sample{At: at, Values: map[string]float64{"metric": 1}}, nil
+}
+
+func (failingRangeFetcherV4) FetchRange(context.Context, time.Time, time.Time, time.Duration) ([]fetch.Sample, error) {
+	return nil, fmt.Errorf("query_range: 503")
+}
+
+func TestSessionV4KeepsRangeFetchWarningsPerWindow(t *testing.T) {
+	session := NewSessionV4(SessionV4Config{
+		TestCase:       "case",
+		RunID:          "run-1",
+		Fetcher:        failingRangeFetcherV4{},
+		SampleInterval: time.Second,
+	})
+
+	for i := 0; i < 2; i++ {
+		session.Start()
+		sum, err := session.End(context.Background())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(sum.Warnings) != 1 || !strings.Contains(sum.Warnings[0], "range fetch failed") {
+			t.Fatalf("window %d: expected one range fetch warning, got %v", i, sum.Warnings)
+		}
+	}
+	if len(session.Warnings) != 0 {
+		t.Fatalf("expected no session-wide warnings, got %v", session.Warnings)
+	}
+}
+
+func TestSessionV4RetriesOnlyConfiguredCurlPods(t *testing.T) {
+	session := NewSessionV4(SessionV4Config{TestCase: "case", RunID: "run-1"})
+	if _, ok := session.metricsFetcher().(*fetch.RetryFetcher); ok {
+		t.Fatal("expected the curl pod fetcher not to be retried by default")
+	}
+	session.Config.Retry = &fetch.RetryPolicy{Attempts: 2}
+	if _, ok := session.metricsFetcher().(*fetch.RetryFetcher); !ok {
+		t.Fatal("expected an explicit retry policy to wrap the curl pod fetcher")
+	}
+}
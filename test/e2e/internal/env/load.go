@@ -1,6 +1,7 @@
 package env
 
 import (
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
@@ -13,7 +14,7 @@ func LoadOptions() Options {
 		Enabled: boolEnv("SLOLAB_ENABLED", false),
 
 		ArtifactsDir: stringEnv("ARTIFACTS_DIR", "/tmp"),
-		RunID:        stringEnv("CI_RUN_ID", ""),
+		RunID:        stringEnv(RunIDEnv, ""),
 
 		SkipCleanup:            boolEnv("E2E_SKIP_CLEANUP", false),
 		SkipCertManagerInstall: boolEnv("CERT_MANAGER_INSTALL_SKIP", false),
@@ -22,6 +23,21 @@ func LoadOptions() Options {
 	}
 }
 
+// RunIDEnv is the environment variable of Options.RunID.
+const RunIDEnv = "CI_RUN_ID"
+
+// ResolveRunID returns CI_RUN_ID, or local-<unix> when it is not set.
+// Resolve it once per suite (on the first parallel process) and ExportRunID the result in
+// every process, so the sessions of all processes and the run report share one id.
+func ResolveRunID() string {
+	return stringEnv(RunIDEnv, fmt.Sprintf("local-%d", time.Now().Unix()))
+}
+
+// ExportRunID sets the run id read by the LoadOptions calls of this process.
+func ExportRunID(id string) error {
+	return os.Setenv(RunIDEnv, id)
+}
+
 // --- helpers (규칙 통일: "1"/"true"/"yes"/"on" 모두 허용) ---
 
 // stringEnv returns environment variable as string.
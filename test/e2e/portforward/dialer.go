@@ -0,0 +1,235 @@
+package portforward
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// Forward is one open tunnel to a pod port.
+type Forward interface {
+	// Addr is the local host:port of the tunnel.
+	Addr() string
+	// Done is closed when the tunnel is gone (pod restarted, connection lost, Close).
+	Done() <-chan struct{}
+	Close()
+}
+
+// Dialer opens forwards. Tests plug in a dialer that points at an httptest server.
+type Dialer interface {
+	Dial(ctx context.Context) (Forward, error)
+}
+
+// SPDYDialer forwards a local port to a ready pod behind a Service,
+// the same way `kubectl port-forward svc/<name>` does.
+type SPDYDialer struct {
+	Config *rest.Config
+	// Client is built from Config when nil.
+	Client kubernetes.Interface
+
+	Namespace   string
+	ServiceName string
+	// ServicePort selects the service port by name or number ("" = first port).
+	ServicePort string
+	// Pod pins the forward to one pod behind the service ("" = first ready pod by name).
+	Pod string
+}
+
+// Dial resolves the pod and port behind the service and opens a forward on 127.0.0.1:<random>.
+func (d *SPDYDialer) Dial(ctx context.Context) (Forward, error) {
+	if d.Config == nil {
+		return nil, errors.New("port-forward: rest config is required")
+	}
+	client, err := clientFor(d.Config, d.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	pod, port, err := d.resolve(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	transport, upgrader, err := spdy.RoundTripperFor(d.Config)
+	if err != nil {
+		return nil, fmt.Errorf("port-forward: %w", err)
+	}
+	url := client.CoreV1().RESTClient().Post().
+		Resource("pods").Namespace(pod.Namespace).Name(pod.Name).SubResource("portforward").URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, url)
+
+	stopCh := make(chan struct{})
+	readyCh := make(chan struct{})
+	pf, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"},
+		[]string{fmt.Sprintf("0:%d", port)}, stopCh, readyCh, io.Discard, io.Discard)
+	if err != nil {
+		return nil, fmt.Errorf("port-forward: %w", err)
+	}
+
+	done := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- pf.ForwardPorts()
+		close(done)
+	}()
+
+	select {
+	case <-readyCh:
+	case err := <-errCh:
+		return nil, fmt.Errorf("port-forward %s/%s:%d: %w", pod.Namespace, pod.Name, port, err)
+	case <-ctx.Done():
+		close(stopCh)
+		return nil, ctx.Err()
+	}
+
+	ports, err := pf.GetPorts()
+	if err != nil || len(ports) == 0 {
+		close(stopCh)
+		return nil, fmt.Errorf("port-forward %s/%s: no local port: %v", pod.Namespace, pod.Name, err)
+	}
+	return &spdyForward{
+		addr: net.JoinHostPort("127.0.0.1", strconv.Itoa(int(ports[0].Local))),
+		stop: stopCh,
+		done: done,
+	}, nil
+}
+
+// resolve picks a running, ready pod selected by the service and the container port to forward to.
+func (d *SPDYDialer) resolve(ctx context.Context, client kubernetes.Interface) (*corev1.Pod, int, error) {
+	svcPort, ready, err := serviceBackends(ctx, client, d.Namespace, d.ServiceName, d.ServicePort)
+	if err != nil {
+		return nil, 0, err
+	}
+	var pod *corev1.Pod
+	for _, p := range ready {
+		if d.Pod == "" || p.Name == d.Pod {
+			pod = p
+			break
+		}
+	}
+	if pod == nil {
+		return nil, 0, fmt.Errorf("port-forward: pod %s/%s is not a ready pod of service %s",
+			d.Namespace, d.Pod, d.ServiceName)
+	}
+
+	port, err := containerPort(pod, svcPort)
+	if err != nil {
+		return nil, 0, err
+	}
+	return pod, port, nil
+}
+
+// serviceBackends returns the selected service port and the ready pods behind the service.
+func serviceBackends(
+	ctx context.Context, client kubernetes.Interface, namespace, name, port string,
+) (corev1.ServicePort, []*corev1.Pod, error) {
+	svc, err := client.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return corev1.ServicePort{}, nil, fmt.Errorf("port-forward: get service %s/%s: %w", namespace, name, err)
+	}
+	svcPort, err := servicePort(svc, port)
+	if err != nil {
+		return corev1.ServicePort{}, nil, err
+	}
+
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(svc.Spec.Selector).String(),
+	})
+	if err != nil {
+		return corev1.ServicePort{}, nil, fmt.Errorf("port-forward: list pods of %s: %w", name, err)
+	}
+	ready := readyPods(pods.Items)
+	if len(ready) == 0 {
+		return corev1.ServicePort{}, nil, fmt.Errorf("port-forward: no ready pod behind service %s/%s", namespace, name)
+	}
+	return svcPort, ready, nil
+}
+
+func clientFor(cfg *rest.Config, client kubernetes.Interface) (kubernetes.Interface, error) {
+	if client != nil {
+		return client, nil
+	}
+	c, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("port-forward: build client: %w", err)
+	}
+	return c, nil
+}
+
+func servicePort(svc *corev1.Service, want string) (corev1.ServicePort, error) {
+	for _, p := range svc.Spec.Ports {
+		if want == "" || p.Name == want || strconv.Itoa(int(p.Port)) == want {
+			return p, nil
+		}
+	}
+	return corev1.ServicePort{}, fmt.Errorf("port-forward: service %s has no port %q", svc.Name, want)
+}
+
+// containerPort maps the service targetPort (number or container port name) to a pod port.
+func containerPort(pod *corev1.Pod, sp corev1.ServicePort) (int, error) {
+	switch {
+	case sp.TargetPort.Type == intstr.String && sp.TargetPort.StrVal != "":
+		for _, c := range pod.Spec.Containers {
+			for _, p := range c.Ports {
+				if p.Name == sp.TargetPort.StrVal {
+					return int(p.ContainerPort), nil
+				}
+			}
+		}
+		return 0, fmt.Errorf("port-forward: pod %s has no container port named %q", pod.Name, sp.TargetPort.StrVal)
+	case sp.TargetPort.IntValue() > 0:
+		return sp.TargetPort.IntValue(), nil
+	default:
+		return int(sp.Port), nil
+	}
+}
+
+// readyPods returns running pods with Ready=True, sorted by name for a stable choice.
+func readyPods(items []corev1.Pod) []*corev1.Pod {
+	var out []*corev1.Pod
+	for i := range items {
+		p := &items[i]
+		if p.Status.Phase != corev1.PodRunning || p.DeletionTimestamp != nil {
+			continue
+		}
+		for _, c := range p.Status.Conditions {
+			if c.Type == corev1.PodReady && c.Status == corev1.ConditionTrue {
+				out = append(out, p)
+				break
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+type spdyForward struct {
+	addr string
+	stop chan struct{}
+	done chan struct{}
+}
+
+func (f *spdyForward) Addr() string          { return f.addr }
+func (f *spdyForward) Done() <-chan struct{} { return f.done }
+
+func (f *spdyForward) Close() {
+	select {
+	case <-f.stop:
+	default:
+		close(f.stop)
+	}
+}
@@ -0,0 +1,123 @@
+package portforward
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yeongki/my-operator/pkg/slo/fetch"
+)
+
+// Fetcher scrapes /metrics through a port-forward.
+// One forward is reused for every scrape of a session; when it breaks
+// (pod restart, lost connection) the next scrape dials a new one.
+type Fetcher struct {
+	Dialer Dialer
+
+	// Scheme and Path of the metrics endpoint behind the forward (default https, /metrics).
+	Scheme string
+	Path   string
+
+	// HTTP carries token, TLS and retry options; its URL is set from the forward.
+	HTTP fetch.HTTPConfig
+
+	mu      sync.Mutex
+	fwd     Forward
+	scraper *fetch.HTTPFetcher
+}
+
+// Fetch scrapes through the current forward and parses the body.
+func (f *Fetcher) Fetch(ctx context.Context, at time.Time) (fetch.Sample, error) {
+	body, contentType, err := f.Scrape(ctx)
+	if err != nil {
+		return fetch.Sample{}, err
+	}
+	return fetch.ParseSampleType(body, contentType, at)
+}
+
+// Scrape returns the raw /metrics body and its Content-Type, and redials once if the
+// forward is broken.
+func (f *Fetcher) Scrape(ctx context.Context) ([]byte, string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		scraper, err := f.connect(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		body, contentType, err := scraper.Scrape(ctx)
+		if err == nil || attempt > 0 || !f.broken(err) {
+			return body, contentType, err
+		}
+		f.closeLocked()
+	}
+}
+
+// Close stops the forward. The fetcher stays usable and dials again on the next Fetch.
+func (f *Fetcher) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closeLocked()
+}
+
+func (f *Fetcher) connect(ctx context.Context) (*fetch.HTTPFetcher, error) {
+	if f.fwd != nil {
+		select {
+		case <-f.fwd.Done():
+			f.closeLocked()
+		default:
+			return f.scraper, nil
+		}
+	}
+
+	fwd, err := f.Dialer.Dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cfg := f.HTTP
+	cfg.URL = f.endpoint(fwd.Addr())
+	scraper, err := fetch.NewHTTPFetcher(cfg)
+	if err != nil {
+		fwd.Close()
+		return nil, err
+	}
+	f.fwd, f.scraper = fwd, scraper
+	return scraper, nil
+}
+
+// broken reports whether err means the tunnel itself failed (as opposed to an HTTP error status).
+func (f *Fetcher) broken(err error) bool {
+	select {
+	case <-f.fwd.Done():
+		return true
+	default:
+	}
+	var uerr *url.Error
+	return errors.As(err, &uerr)
+}
+
+func (f *Fetcher) closeLocked() {
+	if f.fwd != nil {
+		f.fwd.Close()
+	}
+	f.fwd, f.scraper = nil, nil
+}
+
+func (f *Fetcher) endpoint(addr string) string {
+	scheme := f.Scheme
+	if scheme == "" {
+		scheme = "https"
+	}
+	path := f.Path
+	if path == "" {
+		path = "/metrics"
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return scheme + "://" + addr + path
+}
@@ -0,0 +1,113 @@
+package portforward
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeForward struct {
+	addr string
+	done chan struct{}
+	once sync.Once
+}
+
+func (f *fakeForward) Addr() string          { return f.addr }
+func (f *fakeForward) Done() <-chan struct{} { return f.done }
+func (f *fakeForward) Close()                { f.once.Do(func() { close(f.done) }) }
+
+// fakeDialer hands out forwards to addrs in order (the last one repeats).
+type fakeDialer struct {
+	addrs []string
+	dials []*fakeForward
+}
+
+func (d *fakeDialer) Dial(context.Context) (Forward, error) {
+	addr := d.addrs[0]
+	if len(d.addrs) > 1 {
+		d.addrs = d.addrs[1:]
+	}
+	fwd := &fakeForward{addr: addr, done: make(chan struct{})}
+	d.dials = append(d.dials, fwd)
+	return fwd, nil
+}
+
+func newMetricsServer(t *testing.T) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metrics" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("controller_runtime_reconcile_total{result=\"success\"} 4\n"))
+	}))
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+func TestFetcherReusesForward(t *testing.T) {
+	dialer := &fakeDialer{addrs: []string{newMetricsServer(t)}}
+	f := &Fetcher{Dialer: dialer, Scheme: "http"}
+	defer f.Close()
+
+	for i := 0; i < 3; i++ {
+		sample, err := f.Fetch(context.Background(), time.Now())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if sample.Values[`controller_runtime_reconcile_total{result="success"}`] != 4 {
+			t.Fatalf("unexpected sample %v", sample.Values)
+		}
+	}
+	if len(dialer.dials) != 1 {
+		t.Fatalf("expected one forward for the session, got %d", len(dialer.dials))
+	}
+}
+
+func TestFetcherReconnects(t *testing.T) {
+	// a listener that is already closed behaves like a forward whose pod went away
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	dead := l.Addr().String()
+	_ = l.Close()
+
+	dialer := &fakeDialer{addrs: []string{dead, newMetricsServer(t)}}
+	f := &Fetcher{Dialer: dialer, Scheme: "http"}
+	defer f.Close()
+
+	if _, err := f.Fetch(context.Background(), time.Now()); err != nil {
+		t.Fatalf("expected redial to recover, got %v", err)
+	}
+	if len(dialer.dials) != 2 {
+		t.Fatalf("expected a redial, got %d dials", len(dialer.dials))
+	}
+
+	// forward reported gone between scrapes (e.g. pod restart)
+	dialer.dials[1].Close()
+	if _, err := f.Fetch(context.Background(), time.Now()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(dialer.dials) != 3 {
+		t.Fatalf("expected a new forward after Done, got %d dials", len(dialer.dials))
+	}
+}
+
+func TestFetcherDoesNotRedialOnHTTPErrors(t *testing.T) {
+	dialer := &fakeDialer{addrs: []string{newMetricsServer(t)}}
+	f := &Fetcher{Dialer: dialer, Scheme: "http", Path: "wrong"}
+	defer f.Close()
+
+	if _, err := f.Fetch(context.Background(), time.Now()); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected 404, got %v", err)
+	}
+	if len(dialer.dials) != 1 {
+		t.Fatalf("expected no redial for an HTTP error, got %d dials", len(dialer.dials))
+	}
+}
@@ -0,0 +1,96 @@
+package portforward
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/yeongki/my-operator/pkg/slo/fetch"
+)
+
+// ServiceTargets discovers every ready pod behind the metrics Service and scrapes each one
+// through its own port-forward (fetch.TargetDiscoverer). Targets are named by pod.
+// Forwards are reused across snapshots and closed when their pod disappears.
+type ServiceTargets struct {
+	Config *rest.Config
+	// Client is built from Config when nil.
+	Client kubernetes.Interface
+
+	Namespace   string
+	ServiceName string
+	ServicePort string
+
+	// Scheme, Path and HTTP configure each pod's Fetcher (see Fetcher).
+	Scheme string
+	Path   string
+	HTTP   fetch.HTTPConfig
+
+	// NewDialer replaces the SPDY dialer of one pod (tests).
+	NewDialer func(pod string) Dialer
+
+	mu       sync.Mutex
+	fetchers map[string]*Fetcher
+}
+
+// Targets lists the ready pods and returns one target per pod.
+func (t *ServiceTargets) Targets(ctx context.Context) ([]fetch.Target, error) {
+	client, err := clientFor(t.Config, t.Client)
+	if err != nil {
+		return nil, err
+	}
+	_, ready, err := serviceBackends(ctx, client, t.Namespace, t.ServiceName, t.ServicePort)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.fetchers == nil {
+		t.fetchers = map[string]*Fetcher{}
+	}
+
+	current := map[string]bool{}
+	out := make([]fetch.Target, 0, len(ready))
+	for _, pod := range ready {
+		current[pod.Name] = true
+		f, ok := t.fetchers[pod.Name]
+		if !ok {
+			f = &Fetcher{Dialer: t.dialer(client, pod.Name), Scheme: t.Scheme, Path: t.Path, HTTP: t.HTTP}
+			t.fetchers[pod.Name] = f
+		}
+		out = append(out, fetch.Target{Instance: pod.Name, Scraper: f})
+	}
+	for name, f := range t.fetchers {
+		if !current[name] {
+			f.Close()
+			delete(t.fetchers, name)
+		}
+	}
+	return out, nil
+}
+
+// Close stops every forward.
+func (t *ServiceTargets) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for name, f := range t.fetchers {
+		f.Close()
+		delete(t.fetchers, name)
+	}
+}
+
+func (t *ServiceTargets) dialer(client kubernetes.Interface, pod string) Dialer {
+	if t.NewDialer != nil {
+		return t.NewDialer(pod)
+	}
+	return &SPDYDialer{
+		Config:      t.Config,
+		Client:      client,
+		Namespace:   t.Namespace,
+		ServiceName: t.ServiceName,
+		ServicePort: t.ServicePort,
+		Pod:         pod,
+	}
+}
@@ -0,0 +1,76 @@
+package portforward
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/yeongki/my-operator/pkg/slo/fetch"
+)
+
+func readyPod(name string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "sys", Labels: map[string]string{"app": "op"}},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
+func TestServiceTargetsScrapesEveryReadyPod(t *testing.T) {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "op-metrics", Namespace: "sys"},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": "op"},
+			Ports:    []corev1.ServicePort{{Name: "https", Port: 8443}},
+		},
+	}
+	client := fake.NewClientset(svc, readyPod("op-b"), readyPod("op-a"))
+
+	dialers := map[string]*fakeDialer{}
+	targets := &ServiceTargets{
+		Client:      client,
+		Namespace:   "sys",
+		ServiceName: "op-metrics",
+		Scheme:      "http",
+		NewDialer: func(pod string) Dialer {
+			d := &fakeDialer{addrs: []string{newMetricsServer(t)}}
+			dialers[pod] = d
+			return d
+		},
+	}
+	defer targets.Close()
+	f := &fetch.MultiTargetFetcher{Discoverer: targets}
+
+	sample, err := f.Fetch(context.Background(), time.Now())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, pod := range []string{"op-a", "op-b"} {
+		key := `controller_runtime_reconcile_total{instance="` + pod + `",result="success"}`
+		if sample.Values[key] != 4 {
+			t.Fatalf("expected %s=4, got %v", key, sample.Values)
+		}
+	}
+
+	// op-b goes away: its forward is closed, op-a keeps its forward
+	if err := client.CoreV1().Pods("sys").Delete(context.Background(), "op-b", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Fetch(context.Background(), time.Now()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	select {
+	case <-dialers["op-b"].dials[0].Done():
+	default:
+		t.Fatal("expected the forward of the deleted pod to be closed")
+	}
+	if n := len(dialers["op-a"].dials); n != 1 {
+		t.Fatalf("expected op-a to reuse its forward, got %d dials", n)
+	}
+}